@@ -1,6 +1,7 @@
 package httpx
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/mux"
@@ -20,15 +21,54 @@ const (
 )
 
 type healthcheckResponse struct {
-	Status string `json:"status"`
-	Code   int    `json:"code"`
+	Status string                 `json:"status"`
+	Code   int                    `json:"code"`
+	Checks map[string]interface{} `json:"checks,omitempty"`
 }
 
+type healthcheckError struct {
+	Error  string      `json:"error"`
+	Result interface{} `json:"result,omitempty"`
+}
+
+/*
+	Dependency check reporting details like latency, e.g. sqlx.SQLDB.HealthCheck
+ */
+type HealthCheck func(ctx context.Context) (interface{}, error)
+
 func HealthcheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	json.NewEncoder(w).Encode(healthcheckResponse{Status: "OK", Code: 200})
 }
 
+/*
+	Healthcheck running checks with the request context, responds with 503 if any check fails
+ */
+func HealthcheckHandlerWith(checks map[string]HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response := healthcheckResponse{
+			Status: "OK",
+			Code:   http.StatusOK,
+			Checks: make(map[string]interface{}, len(checks)),
+		}
+
+		for name, check := range checks {
+			result, err := check(r.Context())
+			if err != nil {
+				response.Status = "ERROR"
+				response.Code = http.StatusServiceUnavailable
+				response.Checks[name] = healthcheckError{Error: err.Error(), Result: result}
+				continue
+			}
+			response.Checks[name] = result
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(response.Code)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
 func LoggingHandler(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		t1 := time.Now()
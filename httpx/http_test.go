@@ -0,0 +1,75 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ellsol/gox/testx"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func okCheck(ctx context.Context) (interface{}, error) {
+	return map[string]string{"latency": "1ms"}, nil
+}
+
+func failingCheck(ctx context.Context) (interface{}, error) {
+	return map[string]string{"latency": "5s"}, errors.New("connection refused")
+}
+
+func serveHealthcheck(checks map[string]HealthCheck, t *testing.T) (*httptest.ResponseRecorder, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	HealthcheckHandlerWith(checks)(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	body := make(map[string]interface{})
+	err := json.Unmarshal(recorder.Body.Bytes(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return recorder, body
+}
+
+func TestHealthcheckHandlerWithOK(t *testing.T) {
+	recorder, body := serveHealthcheck(map[string]HealthCheck{"database": okCheck}, t)
+
+	if testx.CompareInt("code", http.StatusOK, recorder.Code, t) {
+		return
+	}
+
+	if testx.CompareString("status", "OK", body["status"].(string), t) {
+		return
+	}
+
+	database := body["checks"].(map[string]interface{})["database"].(map[string]interface{})
+	if testx.CompareString("latency", "1ms", database["latency"].(string), t) {
+		return
+	}
+}
+
+func TestHealthcheckHandlerWithFailure(t *testing.T) {
+	recorder, body := serveHealthcheck(map[string]HealthCheck{"database": failingCheck, "cache": okCheck}, t)
+
+	if testx.CompareInt("code", http.StatusServiceUnavailable, recorder.Code, t) {
+		return
+	}
+
+	if testx.CompareString("status", "ERROR", body["status"].(string), t) {
+		return
+	}
+
+	checks := body["checks"].(map[string]interface{})
+	database := checks["database"].(map[string]interface{})
+	if testx.CompareString("error", "connection refused", database["error"].(string), t) {
+		return
+	}
+
+	if testx.CompareString("result", "5s", database["result"].(map[string]interface{})["latency"].(string), t) {
+		return
+	}
+
+	if _, ok := checks["cache"]; !ok {
+		t.Errorf("Param cache [Expected result of passing check, Actual: missing]")
+	}
+}
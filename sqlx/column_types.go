@@ -0,0 +1,144 @@
+package sqlx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+)
+
+const (
+	CreateEnumStatement = "CREATE TYPE %v AS ENUM (%v);"
+)
+
+/*
+	NUMERIC with precision and scale, e.g. NUMERIC(10,2)
+ */
+func NumericType(precision int, scale int) string {
+	return fmt.Sprintf("NUMERIC(%v,%v)", precision, scale)
+}
+
+func VarCharType(length int) string {
+	return fmt.Sprintf("VARCHAR(%v)", length)
+}
+
+/*
+	Array of elementType, e.g. TEXT[]
+ */
+func ArrayType(elementType string) string {
+	return elementType + "[]"
+}
+
+//////////////////////////////////////
+//
+// Enum
+//
+/////////////////////////////////////
+
+/*
+	Postgres enum type, created before the tables using it
+ */
+type SQLEnum struct {
+	TypeName string
+	Values   []string
+}
+
+func NewSQLEnum(typeName string, values ...string) *SQLEnum {
+	return &SQLEnum{
+		TypeName: typeName,
+		Values:   values,
+	}
+}
+
+func (it *SQLEnum) CreateStatement() string {
+	return fmt.Sprintf(CreateEnumStatement, it.TypeName, strings.Join(it.quotedValues(), ", "))
+}
+
+func (it *SQLEnum) quotedValues() []string {
+	values := make([]string, len(it.Values))
+	for k, v := range it.Values {
+		values[k] = "'" + strings.Replace(v, "'", "''", -1) + "'"
+	}
+	return values
+}
+
+func enumStatements(enums []*SQLEnum) []string {
+	result := make([]string, 0, len(enums))
+	for _, v := range enums {
+		result = append(result, v.CreateStatement())
+	}
+	return result
+}
+
+func appendEnum(enums []*SQLEnum, enum *SQLEnum) []*SQLEnum {
+	for _, v := range enums {
+		if v.TypeName == enum.TypeName {
+			return enums
+		}
+	}
+	return append(enums, enum)
+}
+
+//////////////////////////////////////
+//
+// Value conversion
+//
+/////////////////////////////////////
+
+/*
+	Insert parameter storing v as JSON
+ */
+func JSONValue(v interface{}) driver.Valuer {
+	return &jsonValue{value: v}
+}
+
+/*
+	Scan destination unmarshalling a JSON column into dest, which has to be a pointer. NULL leaves dest untouched
+ */
+func JSONScanner(dest interface{}) sql.Scanner {
+	return &jsonValue{value: dest}
+}
+
+type jsonValue struct {
+	value interface{}
+}
+
+func (it *jsonValue) Value() (driver.Value, error) {
+	if it.value == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(it.value)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (it *jsonValue) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, it.value)
+	case string:
+		return json.Unmarshal([]byte(v), it.value)
+	}
+	return fmt.Errorf("can not scan %T into json", src)
+}
+
+/*
+	Insert parameter for a slice stored in an array column, e.g. []string for TEXT[]. Postgres only
+ */
+func ArrayValue(v interface{}) driver.Valuer {
+	return pq.Array(v)
+}
+
+/*
+	Scan destination for an array column, dest has to be a pointer to a slice. Postgres only
+ */
+func ArrayScanner(dest interface{}) sql.Scanner {
+	return pq.Array(dest)
+}
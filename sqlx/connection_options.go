@@ -0,0 +1,159 @@
+package sqlx
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+
+	DefaultMaxIdleConns = 20
+	redactedPassword    = "xxxxx"
+)
+
+/*
+	Postgres connection and pool settings, zero values are left to the driver defaults
+	except SSLMode, which defaults to disable, and MaxIdleConns, which defaults to DefaultMaxIdleConns
+ */
+type ConnectionOptions struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+	// sets the search_path of the connection to schema,public if not empty
+	Schema string
+
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	ConnectTimeout  time.Duration
+	ApplicationName string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+/*
+	Connection string in the key=value format of lib/pq, values are quoted and escaped where needed
+ */
+func (it *ConnectionOptions) DSN() string {
+	params := make([]string, 0)
+	add := func(key string, value string) {
+		if value != "" {
+			params = append(params, key+"="+escapeDSNValue(value))
+		}
+	}
+
+	add("host", it.Host)
+	if it.Port > 0 {
+		add("port", fmt.Sprintf("%v", it.Port))
+	}
+	add("user", it.User)
+	add("password", it.Password)
+	add("dbname", it.DBName)
+
+	sslMode := it.SSLMode
+	if sslMode == "" {
+		sslMode = SSLModeDisable
+	}
+	add("sslmode", sslMode)
+	add("sslrootcert", it.SSLRootCert)
+	add("sslcert", it.SSLCert)
+	add("sslkey", it.SSLKey)
+
+	if it.ConnectTimeout > 0 {
+		// seconds, rounded up as 0 means wait forever
+		add("connect_timeout", fmt.Sprintf("%v", int64((it.ConnectTimeout+time.Second-1)/time.Second)))
+	}
+	add("application_name", it.ApplicationName)
+
+	if it.Schema != "" {
+		add("search_path", it.Schema+",public")
+	}
+
+	return strings.Join(params, " ")
+}
+
+/*
+	DSN with the password replaced, safe for logging
+ */
+func (it *ConnectionOptions) RedactedDSN() string {
+	return RedactDSN(it.DSN())
+}
+
+/*
+	Copy of the options connecting to database instead
+ */
+func (it *ConnectionOptions) WithDatabase(database string) *ConnectionOptions {
+	result := *it
+	result.DBName = database
+	return &result
+}
+
+func (it *ConnectionOptions) configurePool(connection *sql.DB) {
+	maxIdleConns := it.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = DefaultMaxIdleConns
+	}
+	connection.SetMaxIdleConns(maxIdleConns)
+
+	if it.MaxOpenConns > 0 {
+		connection.SetMaxOpenConns(it.MaxOpenConns)
+	}
+
+	if it.ConnMaxLifetime > 0 {
+		connection.SetConnMaxLifetime(it.ConnMaxLifetime)
+	}
+}
+
+/*
+	Opens a postgres connection configured by options
+ */
+func OpenSqlDBWithOptions(options *ConnectionOptions) (*SQLDB, error) {
+	logMsg(fmt.Sprintf("Trying to open connection to postgres with: %v", options.RedactedDSN()))
+	connection, err := sql.Open("postgres", options.DSN())
+	if err != nil {
+		return nil, err
+	}
+
+	options.configurePool(connection)
+
+	return &SQLDB{
+		Connection: connection,
+	}, nil
+}
+
+// values with whitespace, quotes or backslashes have to be quoted, quotes and backslashes escaped
+func escapeDSNValue(value string) string {
+	if !strings.ContainsAny(value, " \t\n\r'\\") {
+		return value
+	}
+
+	escaped := strings.Replace(value, `\`, `\\`, -1)
+	escaped = strings.Replace(escaped, `'`, `\'`, -1)
+	return "'" + escaped + "'"
+}
+
+var (
+	dsnPasswordPattern = regexp.MustCompile(`(password\s*=\s*)('(\\.|[^'\\])*'|\S+)`)
+	urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+)
+
+/*
+	Replaces the password of a key=value or URL connection string
+ */
+func RedactDSN(dsn string) string {
+	result := dsnPasswordPattern.ReplaceAllString(dsn, "${1}"+redactedPassword)
+	return urlPasswordPattern.ReplaceAllString(result, "${1}"+redactedPassword+"@")
+}
@@ -1,11 +1,13 @@
 package sqlx
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/ellsol/gox/typex"
 	_ "github.com/lib/pq"
 	"log"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +18,7 @@ const (
 	DropSchemaStatement       = "DROP SCHEMA IF EXISTS %v CASCADE;"
 	CreateTableStatement      = "CREATE TABLE %v %v;"
 	DropTableStatement        = "DROP TABLE IF EXISTS %v;"
+	TableExistsStatement      = "SELECT to_regclass($1) IS NOT NULL;"
 	DeleteStatement           = "DELETE FROM %v WHERE %v = $1;"
 	InsertStatementWithReturn = "INSERT INTO %v(%v) VALUES(%v) returning %v;"
 	InsertStatement           = "INSERT INTO %v(%v) VALUES(%v);"
@@ -25,17 +28,36 @@ const (
 
 type SQLDB struct {
 	Connection *sql.DB
+	// Postgres if not set
+	Dialect Dialect
 }
 
 type SqlDBInfo struct {
 	Host     string
+	Port     int
 	User     string
 	Password string
 	DBName   string
+	// sets the search_path of the connection if not empty
+	Schema string
+	// disable if empty
+	SSLMode string
+}
+
+func (pi *SqlDBInfo) Options() *ConnectionOptions {
+	return &ConnectionOptions{
+		Host:     pi.Host,
+		Port:     pi.Port,
+		User:     pi.User,
+		Password: pi.Password,
+		DBName:   pi.DBName,
+		Schema:   pi.Schema,
+		SSLMode:  pi.SSLMode,
+	}
 }
 
 func (pi *SqlDBInfo) dbinfo() string {
-	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", pi.Host, pi.User, pi.Password, pi.DBName)
+	return pi.Options().DSN()
 }
 
 type SQLTable interface {
@@ -44,15 +66,45 @@ type SQLTable interface {
 	CreateStatement() string
 }
 
+/*
+	Opens a connection using the driver of dialect, which has to be imported by the caller
+ */
+func OpenSqlDBWithDialect(dialect Dialect, params string) (*SQLDB, error) {
+	connection, err := sql.Open(dialect.DriverName(), params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQLDB{
+		Connection: connection,
+		Dialect:    dialect,
+	}, nil
+}
+
+func (it *SQLDB) dialect() Dialect {
+	if it.Dialect == nil {
+		return Postgres
+	}
+	return it.Dialect
+}
+
+func (it *SQLDB) executor() executor {
+	return newDialectExecutor(it.Connection, it.dialect())
+}
+
+/*
+	Opens a postgres connection with a DSN, see ConnectionOptions to configure the pool as well
+ */
 func OpenSqlDB(params string) (*SQLDB, error) {
-	fmt.Println("Trying to open connection to postgres with: ", params)
+	logMsg(fmt.Sprintf("Trying to open connection to postgres with: %v", RedactDSN(params)))
 	connection, err := sql.Open("postgres", params)
 
 	if err != nil {
 		return nil, err
 	}
 
-	connection.SetMaxIdleConns(20)
+	connection.SetMaxIdleConns(DefaultMaxIdleConns)
 
 	return &SQLDB{
 		Connection: connection,
@@ -60,20 +112,32 @@ func OpenSqlDB(params string) (*SQLDB, error) {
 }
 
 func (db *SQLDB) InitializeDatabase(databaseName string, schema string, tables map[string]SQLTable, forceRecreate bool) error {
+	return db.InitializeDatabaseContext(context.Background(), databaseName, schema, tables, forceRecreate)
+}
+
+/*
+	Creates schema and tables. Tables implementing SchemaTable are created inside schema, so the same tables
+	can be initialized once per tenant schema
+ */
+func (db *SQLDB) InitializeDatabaseContext(ctx context.Context, databaseName string, schema string, tables map[string]SQLTable, forceRecreate bool) error {
+	if db.dialect().SupportsSchemas() {
+		tables = TablesInSchema(schema, tables)
+	}
+
 	logMsg(fmt.Sprintf("initializing db %v with scheme %v and forceRecreate: %v", databaseName, schema, forceRecreate))
 	if forceRecreate {
-		err := db.DropSchemaIfExist(schema)
+		err := db.DropSchemaIfExistContext(ctx, schema)
 		if err != nil {
 			return err
 		}
 	}
 
-	err := db.MaybeCreateScheme(schema)
+	err := db.MaybeCreateSchemeContext(ctx, schema)
 	if err != nil {
 		return err
 	}
 
-	err = db.MaybeInitializeTables(tables)
+	err = db.MaybeInitializeTablesContext(ctx, tables)
 	if err != nil {
 		return err
 	}
@@ -82,30 +146,30 @@ func (db *SQLDB) InitializeDatabase(databaseName string, schema string, tables m
 }
 
 func (it *SQLDB) MaybeCreateDatabase(database string) error {
-	statement := fmt.Sprintf(CreateDatabaseStatement, database)
+	return it.MaybeCreateDatabaseContext(context.Background(), database)
+}
 
-	stmt, err := it.Connection.Prepare(statement)
-	if err != nil {
+func (it *SQLDB) MaybeCreateDatabaseContext(ctx context.Context, database string) error {
+	if !it.dialect().SupportsDatabases() {
 		return nil
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec()
-	if err != nil {
-
-		if strings.Contains(err.Error(), "already exists") {
-			return nil
-		}
 
-		return err
-	}
-	return nil
+	statement := fmt.Sprintf(CreateDatabaseStatement, database)
+	return it.maybeExec(ctx, statement)
 }
 
 func (it *SQLDB) DropDatabaseIfExist(database string) (error) {
+	return it.DropDatabaseIfExistContext(context.Background(), database)
+}
+
+func (it *SQLDB) DropDatabaseIfExistContext(ctx context.Context, database string) (error) {
 	statement := fmt.Sprintf(DropDatabaseStatement, database)
-	stmt, err := it.Connection.Prepare(statement)
+	stmt, err := it.Connection.PrepareContext(ctx, statement)
+	if err != nil {
+		return err
+	}
 	defer stmt.Close()
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -113,77 +177,263 @@ func (it *SQLDB) DropDatabaseIfExist(database string) (error) {
 }
 
 func (it *SQLDB) MaybeCreateScheme(scheme string) error {
-	logMsg(fmt.Sprintf("Maybe create schema %v", scheme))
-	statement := fmt.Sprintf(CreateSchemaStatement, scheme)
-	logMsg(fmt.Sprintf("Maybe create schema statement: %v", statement))
-	stmt, err := it.Connection.Prepare(statement)
-	if err != nil {
+	return it.MaybeCreateSchemeContext(context.Background(), scheme)
+}
+
+func (it *SQLDB) MaybeCreateSchemeContext(ctx context.Context, scheme string) error {
+	if !it.dialect().SupportsSchemas() {
 		return nil
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec()
-	if err != nil {
-		logMsg(err.Error())
-		if strings.Contains(err.Error(), "already exists") {
-			return nil
-		}
 
-		return err
-	}
-	return nil
+	logMsg(fmt.Sprintf("Maybe create schema %v", scheme))
+	statement := fmt.Sprintf(CreateSchemaStatement, scheme)
+	return it.maybeExec(ctx, statement)
 }
 
 func (it *SQLDB) DropSchemaIfExist(schema string) (error) {
+	return it.DropSchemaIfExistContext(context.Background(), schema)
+}
+
+func (it *SQLDB) DropSchemaIfExistContext(ctx context.Context, schema string) (error) {
+	if !it.dialect().SupportsSchemas() {
+		return nil
+	}
+
 	logMsg(fmt.Sprintf("Dropping schema %v", schema))
 	statement := fmt.Sprintf(DropSchemaStatement, schema)
 	logMsg(fmt.Sprintf("Dropping schema statement: %v", statement))
-	stmt, err := it.Connection.Prepare(statement)
+	stmt, err := it.Connection.PrepareContext(ctx, statement)
 
 	if err != nil {
 		return err
 	}
 
 	defer stmt.Close()
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 
 	return err
 }
 
 func (it *SQLDB) MaybeCreateTable(table SQLTable) (error) {
-	logMsg(table.CreateStatement())
-	stmt, err := it.Connection.Prepare(table.CreateStatement())
+	return it.MaybeCreateTableContext(context.Background(), table)
+}
+
+/*
+	Creates table with its enum types and indexes, as far as the dialect supports them
+ */
+func (it *SQLDB) MaybeCreateTableContext(ctx context.Context, table SQLTable) (error) {
+	if typed, ok := table.(TypedTable); ok && it.dialect().SupportsEnumTypes() {
+		for _, v := range typed.TypeStatements() {
+			err := it.maybeExec(ctx, v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	statement := createTableStatement(it.dialect(), table)
+	err := it.maybeExec(ctx, statement)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
-	_, err = stmt.Exec()
-	if err != nil {
-		if strings.Contains(err.Error(), "already exists") {
-			return nil
-		}
 
-		return err
+	// dialect tables declare their indexes in the create statement for such dialects
+	_, inline := table.(DialectTable)
+	if indexed, ok := table.(IndexedTable); ok && !(inline && it.dialect().InlineIndexes()) {
+		for _, v := range indexed.IndexStatements() {
+			err = it.maybeExec(ctx, v)
+			if err != nil {
+				return err
+			}
+		}
 	}
+
 	return nil
 }
 
+// executes statement, ignoring that the created object already exists. Only postgres errors are
+// recognized, statements for other dialects need IF NOT EXISTS, see Dialect.CreateTablePrefix
+func (it *SQLDB) maybeExec(ctx context.Context, statement string) error {
+	logMsg(statement)
+	stmt, err := it.Connection.PrepareContext(ctx, statement)
+	if err != nil {
+		return ignoreAlreadyExists(err)
+	}
+	defer stmt.Close()
+	_, err = stmt.ExecContext(ctx)
+	return ignoreAlreadyExists(err)
+}
+
+// whether any of tables exists, names are resolved like in statements, e.g. using the search_path
+func anyTableExists(ctx context.Context, ex executor, tables map[string]SQLTable) (bool, error) {
+	for _, v := range tables {
+		var exists bool
+		err := ex.QueryRowContext(ctx, TableExistsStatement, v.Name()).Scan(&exists)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
 func (it *SQLDB) DropTableIfExist(table SQLTable) (error) {
+	return it.DropTableIfExistContext(context.Background(), table)
+}
+
+func (it *SQLDB) DropTableIfExistContext(ctx context.Context, table SQLTable) (error) {
 	statement := fmt.Sprintf(DropTableStatement, table.Name())
-	stmt, err := it.Connection.Prepare(statement)
+	stmt, err := it.Connection.PrepareContext(ctx, statement)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
-	_, err = stmt.Exec()
+	_, err = stmt.ExecContext(ctx)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+/*
+	Tables able to render their create statement for other dialects
+ */
+type DialectTable interface {
+	CreateStatementFor(dialect Dialect) string
+}
+
+/*
+	Tables referencing other tables, see MaybeInitializeTables
+ */
+type DependentTable interface {
+	Dependencies() []string
+}
+
+/*
+	Tables which can be moved into a schema, e.g. SQLTableDefinition
+ */
+type SchemaTable interface {
+	InSchema(schema string) SQLTable
+}
+
+/*
+	Qualifies name with schema, names already containing a schema and empty schemas are left as they are
+ */
+func QualifiedName(schema string, name string) string {
+	if schema == "" || strings.Contains(name, ".") {
+		return name
+	}
+	return schema + "." + name
+}
+
+/*
+	Copy of tables with all unqualified SchemaTable moved into schema, keyed by their qualified name.
+	Other tables are kept as they are
+ */
+func TablesInSchema(schema string, tables map[string]SQLTable) map[string]SQLTable {
+	result := make(map[string]SQLTable, len(tables))
+
+	for k, v := range tables {
+		if schemaTable, ok := v.(SchemaTable); ok && schema != "" && !strings.Contains(v.Name(), ".") {
+			qualified := schemaTable.InSchema(schema)
+			result[qualified.Name()] = qualified
+			continue
+		}
+		result[k] = v
+	}
+
+	return result
+}
+
+/*
+	Tables with indexes, which are created by MaybeCreateTable
+ */
+type IndexedTable interface {
+	IndexStatements() []string
+}
+
+/*
+	Tables using own types like enums, which are created by MaybeCreateTable before the table. Postgres only
+ */
+type TypedTable interface {
+	TypeStatements() []string
+}
+
+/*
+	Orders tables so that every table comes after the tables it depends on. Dependencies on tables
+	not part of tables are ignored, cycles are reported as error
+ */
+func SortTablesByDependencies(tables map[string]SQLTable) ([]SQLTable, error) {
+	names := make([]string, 0, len(tables))
+	for k := range tables {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	result := make([]SQLTable, 0, len(tables))
+	// 1 while visiting, 2 when done
+	state := make(map[string]int, len(tables))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("cyclic table dependency: %v", strings.Join(append(path, name), " -> "))
+		case 2:
+			return nil
+		}
+
+		state[name] = 1
+		table := tables[name]
+		if dependent, ok := table.(DependentTable); ok {
+			for _, v := range dependent.Dependencies() {
+				if _, ok := tables[v]; !ok {
+					continue
+				}
+
+				err := visit(v, append(path, name))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		state[name] = 2
+		result = append(result, table)
+		return nil
+	}
+
+	for _, v := range names {
+		err := visit(v, make([]string, 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func createTableStatement(dialect Dialect, table SQLTable) string {
+	if dialectTable, ok := table.(DialectTable); ok {
+		return dialectTable.CreateStatementFor(dialect)
+	}
+	return table.CreateStatement()
+}
+
 func (db *SQLDB) MaybeInitializeTables(tables map[string]SQLTable) error {
-	for _, v := range tables {
-		err := db.MaybeCreateTable(v)
+	return db.MaybeInitializeTablesContext(context.Background(), tables)
+}
+
+/*
+	Creates the tables ordered so that tables referenced by foreign keys are created first
+ */
+func (db *SQLDB) MaybeInitializeTablesContext(ctx context.Context, tables map[string]SQLTable) error {
+	sorted, err := SortTablesByDependencies(tables)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range sorted {
+		err := db.MaybeCreateTableContext(ctx, v)
 
 		if err != nil {
 			return err
@@ -200,21 +450,144 @@ func (db *SQLDB) MaybeInitializeTables(tables map[string]SQLTable) error {
 /////////////////////////////////////////////////////////////////
 
 func (pg *SQLDB) Insert(table SQLTable, values []interface{}) (int, error) {
-	statement := GetPostgresInsertStatementNoIncrement(table)
-	o, err := pg.Connection.Query(statement, values...)
-	if err != nil {
-		return -1, err
-	}
-	var lastInsertId int
-	o.Scan(&lastInsertId)
-	o.Close()
+	return pg.InsertContext(context.Background(), table, values)
+}
 
-	return lastInsertId, nil
+func (pg *SQLDB) InsertContext(ctx context.Context, table SQLTable, values []interface{}) (int, error) {
+	return insert(ctx, pg.executor(), table, values)
 }
 
 func (pg *SQLDB) InsertOmitPrimary(table SQLTable, values []interface{}) (int, error) {
-	statement := GetPostgresInsertStatementNoIncrementOmitPrimary(table) 
-	o, err := pg.Connection.Query(statement, values...)
+	return pg.InsertOmitPrimaryContext(context.Background(), table, values)
+}
+
+func (pg *SQLDB) InsertOmitPrimaryContext(ctx context.Context, table SQLTable, values []interface{}) (int, error) {
+	return insertOmitPrimary(ctx, pg.executor(), table, values)
+}
+
+func (pg *SQLDB) Update(table SQLTable, keyLabel string, values []interface{}) error {
+	return pg.UpdateContext(context.Background(), table, keyLabel, values)
+}
+
+func (pg *SQLDB) UpdateContext(ctx context.Context, table SQLTable, keyLabel string, values []interface{}) error {
+	statement := CreateUpdateStatement(table, keyLabel)
+	return pg.UpdateWithStatementContext(ctx, statement, table, values)
+}
+
+func (pg *SQLDB) UpdateWithStatement(statement string, table SQLTable, values []interface{}) error {
+	return pg.UpdateWithStatementContext(context.Background(), statement, table, values)
+}
+
+func (pg *SQLDB) UpdateWithStatementContext(ctx context.Context, statement string, table SQLTable, values []interface{}) error {
+	return updateWithStatement(ctx, pg.executor(), statement, table, values)
+}
+
+// Delete Row
+func (pg *SQLDB) Delete(key interface{}, keyLabel string, table SQLTable) error {
+	return pg.DeleteContext(context.Background(), key, keyLabel, table)
+}
+
+func (pg *SQLDB) DeleteContext(ctx context.Context, key interface{}, keyLabel string, table SQLTable) error {
+	return deleteRow(ctx, pg.executor(), key, keyLabel, table)
+}
+
+// Number Of Rows
+func (pg *SQLDB) Count(table SQLTable) (int, error) {
+	return pg.CountContext(context.Background(), table)
+}
+
+func (pg *SQLDB) CountContext(ctx context.Context, table SQLTable) (int, error) {
+	return countRows(ctx, pg.executor(), table)
+}
+
+func (it *SQLDB) CountByStatement(table SQLTable, statement string, params ... interface{}) (int, error) {
+	return it.CountByStatementContext(context.Background(), table, statement, params...)
+}
+
+func (it *SQLDB) CountByStatementContext(ctx context.Context, table SQLTable, statement string, params ... interface{}) (int, error) {
+	return countByStatement(ctx, it.executor(), statement, params...)
+}
+
+// Number Of Rows
+func (pg *SQLDB) Max(table SQLTable, column string) (int64, error) {
+	return pg.MaxContext(context.Background(), table, column)
+}
+
+func (pg *SQLDB) MaxContext(ctx context.Context, table SQLTable, column string) (int64, error) {
+	return maxOfColumn(ctx, pg.executor(), table, column)
+}
+
+// Rows selected by builder, rows have to be closed by the caller
+func (pg *SQLDB) Select(builder Statement) (*sql.Rows, error) {
+	return pg.SelectContext(context.Background(), builder)
+}
+
+func (pg *SQLDB) SelectContext(ctx context.Context, builder Statement) (*sql.Rows, error) {
+	return selectRows(ctx, pg.executor(), builder)
+}
+
+/////////////////////////////////////////////////////////////////
+//
+// Implementations shared by SQLDB and Tx
+//
+/////////////////////////////////////////////////////////////////
+
+// implemented by *sql.DB and *sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+/*
+	Rebinds the postgres style statements for the dialect before executing them and classifies
+	the returned errors, see ClassifyError
+ */
+type dialectExecutor struct {
+	executor executor
+	dialect  Dialect
+}
+
+func newDialectExecutor(ex executor, dialect Dialect) executor {
+	if dialect == nil {
+		dialect = Postgres
+	}
+
+	return &dialectExecutor{
+		executor: ex,
+		dialect:  dialect,
+	}
+}
+
+func (it *dialectExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	query, args = Rebind(it.dialect, query, args)
+	result, err := it.executor.ExecContext(ctx, query, args...)
+	return result, ClassifyError(err)
+}
+
+func (it *dialectExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	query, args = Rebind(it.dialect, query, args)
+	rows, err := it.executor.QueryContext(ctx, query, args...)
+	return rows, ClassifyError(err)
+}
+
+func (it *dialectExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	query, args = Rebind(it.dialect, query, args)
+	return it.executor.QueryRowContext(ctx, query, args...)
+}
+
+func insert(ctx context.Context, ex executor, table SQLTable, values []interface{}) (int, error) {
+	statement := GetPostgresInsertStatementNoIncrement(table)
+	return insertWithStatement(ctx, ex, statement, values)
+}
+
+func insertOmitPrimary(ctx context.Context, ex executor, table SQLTable, values []interface{}) (int, error) {
+	statement := GetPostgresInsertStatementNoIncrementOmitPrimary(table)
+	return insertWithStatement(ctx, ex, statement, values)
+}
+
+func insertWithStatement(ctx context.Context, ex executor, statement string, values []interface{}) (int, error) {
+	o, err := ex.QueryContext(ctx, statement, values...)
 	if err != nil {
 		return -1, err
 	}
@@ -225,13 +598,8 @@ func (pg *SQLDB) InsertOmitPrimary(table SQLTable, values []interface{}) (int, e
 	return lastInsertId, nil
 }
 
-func (pg *SQLDB) Update(table SQLTable, keyLabel string, values []interface{}) error {
-	statement := CreateUpdateStatement(table, keyLabel)
-	return pg.UpdateWithStatement(statement, table, values)
-}
-
-func (pg *SQLDB) UpdateWithStatement(statement string, table SQLTable, values []interface{}) error {
-	updated, err := pg.Connection.Exec(statement, values...)
+func updateWithStatement(ctx context.Context, ex executor, statement string, table SQLTable, values []interface{}) error {
+	updated, err := ex.ExecContext(ctx, statement, values...)
 
 	if err != nil {
 		return err
@@ -242,6 +610,10 @@ func (pg *SQLDB) UpdateWithStatement(statement string, table SQLTable, values []
 		return err
 	}
 
+	if count == 0 {
+		return fmt.Errorf("failed to update %v: %w", table.Name(), ErrNotFound)
+	}
+
 	if count != 1 {
 		return fmt.Errorf("failed to update %v", table.Name())
 	}
@@ -249,10 +621,14 @@ func (pg *SQLDB) UpdateWithStatement(statement string, table SQLTable, values []
 	return nil
 }
 
-// Delete Row
-func (pg *SQLDB) Delete(key interface{}, keyLabel string, table SQLTable) error {
+func deleteRow(ctx context.Context, ex executor, key interface{}, keyLabel string, table SQLTable) error {
+	err := ValidateIdentifier(keyLabel)
+	if err != nil {
+		return err
+	}
+
 	sqlStatement := fmt.Sprintf(DeleteStatement, table.Name(), keyLabel)
-	_, err := pg.Connection.Exec(sqlStatement, key)
+	_, err = ex.ExecContext(ctx, sqlStatement, key)
 	if err != nil {
 		return err
 	}
@@ -260,10 +636,9 @@ func (pg *SQLDB) Delete(key interface{}, keyLabel string, table SQLTable) error
 	return nil
 }
 
-// Number Of Rows
-func (pg *SQLDB) Count(table SQLTable) (int, error) {
+func countRows(ctx context.Context, ex executor, table SQLTable) (int, error) {
 	sqlStatement := fmt.Sprintf(NumberOfRowsStatement, table.Name())
-	rows, err := pg.Connection.Query(sqlStatement)
+	rows, err := ex.QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return -1, err
 	}
@@ -279,10 +654,9 @@ func (pg *SQLDB) Count(table SQLTable) (int, error) {
 	return -1, nil
 }
 
-func (it *SQLDB) CountByStatement(table SQLTable, statement string, params ... interface{}) (int, error) {
-
+func countByStatement(ctx context.Context, ex executor, statement string, params ...interface{}) (int, error) {
 	var count int
-	row := it.Connection.QueryRow(statement, params...)
+	row := ex.QueryRowContext(ctx, statement, params...)
 	err := row.Scan(&count)
 	if err != nil {
 		return -1, err
@@ -291,10 +665,14 @@ func (it *SQLDB) CountByStatement(table SQLTable, statement string, params ... i
 	return count, nil
 }
 
-// Number Of Rows
-func (pg *SQLDB) Max(table SQLTable, column string) (int64, error) {
+func maxOfColumn(ctx context.Context, ex executor, table SQLTable, column string) (int64, error) {
+	err := ValidateIdentifier(column)
+	if err != nil {
+		return -1, err
+	}
+
 	sqlStatement := fmt.Sprintf(MaxStatement, column, table.Name())
-	rows, err := pg.Connection.Query(sqlStatement)
+	rows, err := ex.QueryContext(ctx, sqlStatement)
 	if err != nil {
 		return -1, err
 	}
@@ -313,6 +691,14 @@ func (pg *SQLDB) Max(table SQLTable, column string) (int64, error) {
 	return -1, nil
 }
 
+func selectRows(ctx context.Context, ex executor, builder Statement) (*sql.Rows, error) {
+	statement, params, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	return ex.QueryContext(ctx, statement, params...)
+}
+
 // Statements
 
 /*
@@ -328,9 +714,13 @@ func GetPostgresInsertStatementNoIncrement(t SQLTable) string {
 	return fmt.Sprintf(InsertStatement, t.Name(), paramsJoin, paramsPlaceholder)
 }
 
+/*
+	Insert statement for all columns except the primary key, see ColumnNamesOmitPrimary
+ */
 func GetPostgresInsertStatementNoIncrementOmitPrimary(t SQLTable) string {
-	paramsJoin := typex.CommaSeparatedString(t.ColumnNames()[1:])
-	paramsPlaceholder := typex.CommaSeparatedString(typex.MapStringListWithPos(t.ColumnNames()[1:], func(key int, value string) string {
+	columns := ColumnNamesOmitPrimary(t)
+	paramsJoin := typex.CommaSeparatedString(columns)
+	paramsPlaceholder := typex.CommaSeparatedString(typex.MapStringListWithPos(columns, func(key int, value string) string {
 		return fmt.Sprintf("$%v", key+1)
 	}))
 
@@ -338,20 +728,71 @@ func GetPostgresInsertStatementNoIncrementOmitPrimary(t SQLTable) string {
 }
 
 /*
-	 Maps SQLTable to update statement
+	 Maps SQLTable to update statement. The key is expected as first value followed by the values of
+	 ColumnNamesOmitPrimary, which is ColumnNames()[1:] for tables not implementing PrimaryKeyTable.
+	 If keyLabel is part of a composite primary key, all key columns are matched and their values are
+	 expected first in the order of PrimaryKeyColumns
  */
 func CreateUpdateStatement(table SQLTable, keyLabel string) string {
-	set := typex.MapStringListWithPos(table.ColumnNames()[1:], func(pos int, tag string) string {
-		return fmt.Sprintf("%v = $%v", tag, pos+2)
+	keys := []string{keyLabel}
+	if primary := PrimaryKeyColumns(table); typex.StringListContains(keyLabel, primary) {
+		keys = primary
+	}
+
+	set := typex.MapStringListWithPos(ColumnNamesOmitPrimary(table), func(pos int, tag string) string {
+		return fmt.Sprintf("%v = $%v", tag, pos+len(keys)+1)
 	})
 
-	return fmt.Sprintf("UPDATE %v SET %v WHERE %v = $1;", table.Name(), typex.CommaSeparatedString(set), keyLabel)
+	where := typex.MapStringListWithPos(keys, func(pos int, tag string) string {
+		return fmt.Sprintf("%v = $%v", tag, pos+1)
+	})
+
+	return fmt.Sprintf("UPDATE %v SET %v WHERE %v;", table.Name(), typex.CommaSeparatedString(set), strings.Join(where, " AND "))
+}
+
+/*
+	Tables knowing their primary key, e.g. SQLTableDefinition
+ */
+type PrimaryKeyTable interface {
+	PrimaryKeyColumns() []string
+}
+
+/*
+	Primary key columns of table. For tables not implementing PrimaryKeyTable the first column is assumed to be the key
+ */
+func PrimaryKeyColumns(table SQLTable) []string {
+	if keyed, ok := table.(PrimaryKeyTable); ok {
+		return keyed.PrimaryKeyColumns()
+	}
+
+	columns := table.ColumnNames()
+	if len(columns) == 0 {
+		return columns
+	}
+	return columns[:1]
+}
+
+/*
+	Column names of table without the primary key columns, as used by InsertOmitPrimary
+ */
+func ColumnNamesOmitPrimary(table SQLTable) []string {
+	primary := PrimaryKeyColumns(table)
+	result := make([]string, 0)
+
+	for _, v := range table.ColumnNames() {
+		if !typex.StringListContains(v, primary) {
+			result = append(result, v)
+		}
+	}
+
+	return result
 }
 
 var LogDatabase bool = true
 
 func logMsg(msg string) {
 	if(LogDatabase) {
-		log.Println(msg)
+		// messages may contain connection strings, e.g. in driver errors
+		log.Println(RedactDSN(msg))
 	}
 }
\ No newline at end of file
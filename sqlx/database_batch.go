@@ -0,0 +1,201 @@
+package sqlx
+
+import (
+	"context"
+	"bytes"
+	"fmt"
+	"github.com/ellsol/gox/typex"
+	"strings"
+)
+
+const (
+	// postgres refuses statements with more bind parameters than this, see Dialect.MaxStatementParams
+	MaxStatementParams = 65535
+
+	MultiInsertStatement = "INSERT INTO %v(%v) VALUES %v;"
+)
+
+type ChunkError struct {
+	Chunk    int
+	FirstRow int
+	LastRow  int
+	Err      error
+}
+
+func (it *ChunkError) Error() string {
+	return fmt.Sprintf("chunk %v (rows %v-%v): %v", it.Chunk, it.FirstRow, it.LastRow, it.Err)
+}
+
+type InsertManyResult struct {
+	Inserted int
+	Chunks   int
+	Failures []*ChunkError
+}
+
+func (it *InsertManyResult) Error() string {
+	messages := make([]string, len(it.Failures))
+	for k, v := range it.Failures {
+		messages[k] = v.Error()
+	}
+
+	return fmt.Sprintf("%v of %v chunks failed: %v", len(it.Failures), it.Chunks, strings.Join(messages, "; "))
+}
+
+type InsertManyOptions struct {
+	// commits the chunks inserted successfully even if others fail, each chunk is guarded by a savepoint
+	PartialCommit bool
+}
+
+func DefaultInsertManyOptions() *InsertManyOptions {
+	return &InsertManyOptions{
+		PartialCommit: false,
+	}
+}
+
+/*
+	Inserts rows using multi row INSERT statements. The rows are split into chunks so that no statement exceeds
+	the parameter limit of the dialect. All chunks run inside a single transaction, either all rows are inserted
+	or none. If a chunk fails the returned result lists it and is also returned as error.
+ */
+func (pg *SQLDB) InsertMany(table SQLTable, rows [][]interface{}) (*InsertManyResult, error) {
+	return pg.InsertManyContext(context.Background(), table, rows)
+}
+
+func (pg *SQLDB) InsertManyContext(ctx context.Context, table SQLTable, rows [][]interface{}) (*InsertManyResult, error) {
+	return pg.InsertManyWithOptions(ctx, table, rows, DefaultInsertManyOptions())
+}
+
+/*
+	Like InsertMany, with PartialCommit a failing chunk is rolled back on its own and the remaining chunks are
+	still committed. The returned result lists all failing chunks
+ */
+func (pg *SQLDB) InsertManyWithOptions(ctx context.Context, table SQLTable, rows [][]interface{}, options *InsertManyOptions) (*InsertManyResult, error) {
+	if options == nil {
+		options = DefaultInsertManyOptions()
+	}
+
+	result := &InsertManyResult{
+		Failures: make([]*ChunkError, 0),
+	}
+
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	columnCount := len(table.ColumnNames())
+	for k, v := range rows {
+		if len(v) != columnCount {
+			return nil, fmt.Errorf("row %v has %v values, table %v has %v columns", k, len(v), table.Name(), columnCount)
+		}
+	}
+
+	tx, err := pg.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	ex := newDialectExecutor(tx, pg.dialect())
+
+	chunkSize := InsertManyChunkSize(pg.dialect(), columnCount)
+	for chunk, first := 0, 0; first < len(rows); chunk, first = chunk+1, first+chunkSize {
+		last := first + chunkSize
+		if last > len(rows) {
+			last = len(rows)
+		}
+
+		result.Chunks++
+		chunkRows := rows[first:last]
+		params := make([]interface{}, 0, len(chunkRows)*columnCount)
+		for _, v := range chunkRows {
+			params = append(params, v...)
+		}
+
+		savepoint := fmt.Sprintf("insert_many_%v", chunk)
+		if options.PartialCommit {
+			_, err = ex.ExecContext(ctx, "SAVEPOINT "+savepoint)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+		}
+
+		_, err = ex.ExecContext(ctx, CreateMultiInsertStatement(table, len(chunkRows)), params...)
+		if err != nil {
+			result.Failures = append(result.Failures, &ChunkError{
+				Chunk:    chunk,
+				FirstRow: first,
+				LastRow:  last - 1,
+				Err:      err,
+			})
+
+			if !options.PartialCommit {
+				// nothing of the previous chunks is kept
+				tx.Rollback()
+				result.Inserted = 0
+				return result, result
+			}
+
+			_, err = ex.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+			if err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			continue
+		}
+
+		result.Inserted += len(chunkRows)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Failures) > 0 {
+		return result, result
+	}
+
+	return result, nil
+}
+
+/*
+	Number of rows fitting into one multi row insert for a table with columnCount columns, Postgres if dialect is nil
+ */
+func InsertManyChunkSize(dialect Dialect, columnCount int) int {
+	if dialect == nil {
+		dialect = Postgres
+	}
+
+	if columnCount <= 0 || columnCount > dialect.MaxStatementParams() {
+		return 1
+	}
+
+	return dialect.MaxStatementParams() / columnCount
+}
+
+/*
+	Maps SQLTable to multi row insert with rowCount rows
+	INSERT INTO table(tag1, tag2) VALUES ($1,$2),($3,$4);
+ */
+func CreateMultiInsertStatement(table SQLTable, rowCount int) string {
+	columns := table.ColumnNames()
+
+	var buffer bytes.Buffer
+	position := 1
+	for row := 0; row < rowCount; row++ {
+		if row > 0 {
+			buffer.WriteString(",")
+		}
+
+		buffer.WriteString("(")
+		for k := range columns {
+			if k > 0 {
+				buffer.WriteString(",")
+			}
+			buffer.WriteString(fmt.Sprintf("$%v", position))
+			position++
+		}
+		buffer.WriteString(")")
+	}
+
+	return fmt.Sprintf(MultiInsertStatement, table.Name(), typex.CommaSeparatedString(columns), buffer.String())
+}
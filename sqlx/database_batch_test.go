@@ -0,0 +1,110 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/ellsol/gox/testx"
+	"strings"
+	"testing"
+)
+
+func TestCreateMultiInsertStatement(t *testing.T) {
+	table := &testTable{name: "accounts", columns: []string{"id", "name"}}
+
+	expected := "INSERT INTO accounts(id,name) VALUES ($1,$2),($3,$4),($5,$6);"
+	if testx.CompareString("multi insert", expected, CreateMultiInsertStatement(table, 3), t) {
+		return
+	}
+
+	if testx.CompareInt("chunk size", 21845, InsertManyChunkSize(Postgres, 3), t) ||
+		testx.CompareInt("default chunk size", 21845, InsertManyChunkSize(nil, 3), t) ||
+		testx.CompareInt("sqlite chunk size", 333, InsertManyChunkSize(SQLite, 3), t) {
+		return
+	}
+}
+
+// rows with id 0 to count-1, inserting a row with id failing fails its chunk
+func insertManyFixture(t *testing.T, count int, failing int64) (*SQLDB, *fakeState, *testTable, [][]interface{}) {
+	db, state := openFakeDB(t)
+	db.Dialect = SQLite
+	state.onExec = func(query string, args []driver.Value) error {
+		for _, v := range args {
+			if v == failing {
+				return errors.New("failing row")
+			}
+		}
+		return nil
+	}
+
+	rows := make([][]interface{}, count)
+	for k := range rows {
+		rows[k] = []interface{}{int64(k), "name"}
+	}
+
+	return db, state, &testTable{name: "accounts", columns: []string{"id", "name"}}, rows
+}
+
+func TestInsertMany(t *testing.T) {
+	// 499 rows per chunk with the sqlite limit
+	db, state, table, rows := insertManyFixture(t, 1000, -1)
+
+	result, err := db.InsertMany(table, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("inserted", 1000, result.Inserted, t) ||
+		testx.CompareInt("chunks", 3, result.Chunks, t) ||
+		testx.CompareInt("commits", 1, state.commits, t) ||
+		testx.CompareInt("statements", 3, len(state.statements), t) {
+		return
+	}
+
+	if !strings.HasPrefix(state.statements[0], "INSERT INTO accounts(id,name) VALUES (?,?),") {
+		t.Errorf("Param statement [Expected sqlite placeholders, Actual: %.60v]", state.statements[0])
+	}
+}
+
+func TestInsertManyAllOrNothing(t *testing.T) {
+	db, state, table, rows := insertManyFixture(t, 1000, 600)
+
+	result, err := db.InsertMany(table, rows)
+	if err == nil {
+		t.Fatalf("Param err [Expected error, Actual: nil]")
+	}
+
+	if testx.CompareInt("inserted", 0, result.Inserted, t) ||
+		testx.CompareInt("failures", 1, len(result.Failures), t) ||
+		testx.CompareInt("failing chunk", 1, result.Failures[0].Chunk, t) ||
+		testx.CompareInt("commits", 0, state.commits, t) ||
+		testx.CompareInt("rollbacks", 1, state.rollbacks, t) {
+		return
+	}
+
+	// the chunk after the failing one is not attempted
+	if testx.CompareInt("statements", 2, len(state.statements), t) {
+		return
+	}
+}
+
+func TestInsertManyPartialCommit(t *testing.T) {
+	db, state, table, rows := insertManyFixture(t, 1000, 600)
+
+	result, err := db.InsertManyWithOptions(context.Background(), table, rows, &InsertManyOptions{PartialCommit: true})
+	if err == nil {
+		t.Fatalf("Param err [Expected error, Actual: nil]")
+	}
+
+	if testx.CompareInt("inserted", 501, result.Inserted, t) ||
+		testx.CompareInt("failures", 1, len(result.Failures), t) ||
+		testx.CompareInt("first row", 499, result.Failures[0].FirstRow, t) ||
+		testx.CompareInt("last row", 997, result.Failures[0].LastRow, t) ||
+		testx.CompareInt("commits", 1, state.commits, t) {
+		return
+	}
+
+	if testx.CompareString("rollback", "ROLLBACK TO SAVEPOINT insert_many_1", state.statements[4], t) {
+		return
+	}
+}
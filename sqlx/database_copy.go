@@ -0,0 +1,256 @@
+package sqlx
+
+import (
+	"context"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+)
+
+// number of rows between two progress callbacks of CopyFrom
+var CopyProgressInterval int64 = 10000
+
+/*
+	Source of rows for CopyFrom, Next returns io.EOF once all rows have been read
+ */
+type CopySource interface {
+	Next() ([]interface{}, error)
+}
+
+/*
+	Called by CopyFrom every CopyProgressInterval rows and once when done, unless the last call already reported all rows
+ */
+type CopyProgress func(rowsCopied int64)
+
+/*
+	Streams all rows of source into table using postgres COPY FROM STDIN, only available for postgres. The values of each row have to be given
+	in the order of table.ColumnNames(). Runs inside a transaction, either all rows are copied or none.
+	Returns the number of copied rows
+ */
+func (pg *SQLDB) CopyFrom(table SQLTable, source CopySource, progress CopyProgress) (int64, error) {
+	return pg.CopyFromContext(context.Background(), table, source, progress)
+}
+
+func (pg *SQLDB) CopyFromContext(ctx context.Context, table SQLTable, source CopySource, progress CopyProgress) (int64, error) {
+	if pg.dialect() != Postgres {
+		return 0, fmt.Errorf("copy is not supported by %v", pg.dialect().Name())
+	}
+
+	tx, err := pg.Connection.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, copyInStatement(table))
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	count, err := copyRows(table, source, progress, func(values []interface{}) error {
+		_, err := stmt.ExecContext(ctx, values...)
+		return err
+	})
+	if err != nil {
+		stmt.Close()
+		tx.Rollback()
+		return 0, err
+	}
+
+	// flushes the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	if err != nil {
+		stmt.Close()
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = stmt.Close()
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	reportCopyDone(progress, count)
+	return count, nil
+}
+
+// final progress callback, unless copyRows already reported count
+func reportCopyDone(progress CopyProgress, count int64) {
+	if progress == nil {
+		return
+	}
+
+	if count > 0 && CopyProgressInterval > 0 && count%CopyProgressInterval == 0 {
+		return
+	}
+
+	progress(count)
+}
+
+/*
+	Passes every row of source to exec after checking it has a value per column of table, reports progress
+	every CopyProgressInterval rows. Returns the number of rows passed
+ */
+func copyRows(table SQLTable, source CopySource, progress CopyProgress, exec func(values []interface{}) error) (int64, error) {
+	columnCount := len(table.ColumnNames())
+	var count int64 = 0
+	for {
+		values, err := source.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+
+		if err == nil && len(values) != columnCount {
+			err = fmt.Errorf("row %v has %v values, table %v has %v columns", count, len(values), table.Name(), columnCount)
+		}
+
+		if err == nil {
+			err = exec(values)
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		count++
+		if progress != nil && CopyProgressInterval > 0 && count%CopyProgressInterval == 0 {
+			progress(count)
+		}
+	}
+}
+
+// COPY statement quoting schema and table separately, pq.CopyIn would quote a qualified name as one identifier
+func copyInStatement(table SQLTable) string {
+	schema, name := splitQualifiedName(table.Name())
+	if schema == "" {
+		return pq.CopyIn(name, table.ColumnNames()...)
+	}
+	return pq.CopyInSchema(schema, name, table.ColumnNames()...)
+}
+
+//////////////////////////////////////
+//
+// CopySource implementations
+//
+/////////////////////////////////////
+
+type sliceCopySource struct {
+	rows     [][]interface{}
+	position int
+}
+
+func NewSliceCopySource(rows [][]interface{}) CopySource {
+	return &sliceCopySource{
+		rows: rows,
+	}
+}
+
+func (it *sliceCopySource) Next() ([]interface{}, error) {
+	if it.position >= len(it.rows) {
+		return nil, io.EOF
+	}
+
+	row := it.rows[it.position]
+	it.position++
+	return row, nil
+}
+
+/*
+	Anything exposing its values as strings, e.g. csv.CSVRow
+ */
+type StringRow interface {
+	Values() []string
+}
+
+type stringRowCopySource struct {
+	rows     []StringRow
+	position int
+	null     *string
+}
+
+/*
+	Copies the values as they are, empty strings stay empty strings
+ */
+func NewStringRowCopySource(rows []StringRow) CopySource {
+	return &stringRowCopySource{
+		rows: rows,
+	}
+}
+
+/*
+	Like NewStringRowCopySource, values equal to null are copied as NULL. Like the NULL option of COPY,
+	e.g. "" to store empty csv fields as NULL
+ */
+func NewStringRowCopySourceWithNull(rows []StringRow, null string) CopySource {
+	return &stringRowCopySource{
+		rows: rows,
+		null: &null,
+	}
+}
+
+func (it *stringRowCopySource) Next() ([]interface{}, error) {
+	if it.position >= len(it.rows) {
+		return nil, io.EOF
+	}
+
+	row := it.rows[it.position]
+	it.position++
+	return stringsToInterfaces(row.Values(), it.null), nil
+}
+
+/*
+	Anything reading string records until io.EOF, e.g. encoding/csv.Reader on a file written by csv.CSVFile
+ */
+type StringRowReader interface {
+	Read() ([]string, error)
+}
+
+type readerCopySource struct {
+	reader StringRowReader
+	null   *string
+}
+
+/*
+	Copies the values as they are, empty strings stay empty strings
+ */
+func NewReaderCopySource(reader StringRowReader) CopySource {
+	return &readerCopySource{
+		reader: reader,
+	}
+}
+
+/*
+	Like NewReaderCopySource, values equal to null are copied as NULL, see NewStringRowCopySourceWithNull
+ */
+func NewReaderCopySourceWithNull(reader StringRowReader, null string) CopySource {
+	return &readerCopySource{
+		reader: reader,
+		null:   &null,
+	}
+}
+
+func (it *readerCopySource) Next() ([]interface{}, error) {
+	record, err := it.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	return stringsToInterfaces(record, it.null), nil
+}
+
+// values equal to null become NULL, nothing does if null is nil
+func stringsToInterfaces(values []string, null *string) []interface{} {
+	result := make([]interface{}, len(values))
+	for k, v := range values {
+		if null == nil || v != *null {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,213 @@
+package sqlx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/ellsol/gox/testx"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCopyInStatement(t *testing.T) {
+	table := &testTable{name: "orders", columns: []string{"id", "name"}}
+	if testx.CompareString("unqualified", `COPY "orders" ("id", "name") FROM STDIN`, copyInStatement(table), t) {
+		return
+	}
+
+	qualified := NewSQLTableBuilder("orders").
+		WithIntColumn("id").
+		WithTextColumn("name").
+		Build().InSchema("tenant_a")
+	if testx.CompareString("qualified", `COPY "tenant_a"."orders" ("id", "name") FROM STDIN`, copyInStatement(qualified), t) {
+		return
+	}
+}
+
+type testStringRow []string
+
+func (it testStringRow) Values() []string {
+	return it
+}
+
+func readAll(source CopySource, t *testing.T) [][]interface{} {
+	result := make([][]interface{}, 0)
+	for {
+		values, err := source.Next()
+		if err == io.EOF {
+			return result
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, values)
+	}
+}
+
+func TestSliceCopySource(t *testing.T) {
+	rows := readAll(NewSliceCopySource([][]interface{}{{1, "a"}, {2, nil}}), t)
+	if testx.CompareInt("rows", 2, len(rows), t) {
+		return
+	}
+
+	if rows[0][0] != 1 || rows[0][1] != "a" || rows[1][0] != 2 || rows[1][1] != nil {
+		t.Errorf("Param rows [Expected [[1 a] [2 <nil>]], Actual: %v]", rows)
+	}
+
+	if testx.CompareInt("empty", 0, len(readAll(NewSliceCopySource(nil), t)), t) {
+		return
+	}
+}
+
+func TestStringRowCopySource(t *testing.T) {
+	rows := []StringRow{testStringRow{"1", "a"}, testStringRow{"2", ""}, testStringRow{"3", `\N`}}
+	values := readAll(NewStringRowCopySource(rows), t)
+	if testx.CompareInt("rows", 3, len(values), t) {
+		return
+	}
+
+	if values[0][0] != "1" || values[0][1] != "a" || values[1][0] != "2" {
+		t.Errorf("Param rows [Expected [[1 a] [2 ]], Actual: %v]", values)
+	}
+
+	// empty strings round trip by default
+	if values[1][1] != "" {
+		t.Errorf("Param empty [Expected empty string, Actual: %#v]", values[1][1])
+	}
+
+	values = readAll(NewStringRowCopySourceWithNull(rows, `\N`), t)
+	if values[1][1] != "" || values[2][1] != nil {
+		t.Errorf("Param null marker [Expected empty string and <nil>, Actual: %#v %#v]", values[1][1], values[2][1])
+	}
+}
+
+func TestReaderCopySource(t *testing.T) {
+	values := readAll(NewReaderCopySource(csv.NewReader(strings.NewReader("1,a\n2,\n"))), t)
+	if testx.CompareInt("rows", 2, len(values), t) {
+		return
+	}
+
+	if values[0][0] != "1" || values[0][1] != "a" || values[1][0] != "2" || values[1][1] != "" {
+		t.Errorf("Param rows [Expected [[1 a] [2 ]], Actual: %v]", values)
+	}
+
+	// NULL '' like COPY
+	values = readAll(NewReaderCopySourceWithNull(csv.NewReader(strings.NewReader("1,a\n2,\n")), ""), t)
+	if values[0][1] != "a" || values[1][1] != nil {
+		t.Errorf("Param empty [Expected <nil>, Actual: %#v]", values[1][1])
+	}
+}
+
+func TestReaderCopySourceError(t *testing.T) {
+	source := NewReaderCopySource(csv.NewReader(strings.NewReader("1,a\n2\n")))
+	_, err := source.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// encoding/csv rejects records with a different number of fields
+	_, err = source.Next()
+	if err == nil || err == io.EOF {
+		t.Errorf("Param err [Expected csv error, Actual: %v]", err)
+	}
+}
+
+func TestCopyRows(t *testing.T) {
+	defer func(interval int64) { CopyProgressInterval = interval }(CopyProgressInterval)
+	CopyProgressInterval = 2
+
+	table := &testTable{name: "orders", columns: []string{"id", "name"}}
+	source := NewSliceCopySource([][]interface{}{{1, "a"}, {2, "b"}, {3, "c"}, {4, "d"}, {5, "e"}})
+
+	copied := make([][]interface{}, 0)
+	reported := make([]int64, 0)
+	count, err := copyRows(table, source, func(rowsCopied int64) {
+		reported = append(reported, rowsCopied)
+	}, func(values []interface{}) error {
+		copied = append(copied, values)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("count", 5, int(count), t) {
+		return
+	}
+
+	if testx.CompareInt("copied", 5, len(copied), t) {
+		return
+	}
+
+	if copied[4][0] != 5 || copied[4][1] != "e" {
+		t.Errorf("Param last [Expected [5 e], Actual: %v]", copied[4])
+	}
+
+	if testx.CompareString("progress", "[2 4]", fmt.Sprint(reported), t) {
+		return
+	}
+}
+
+func TestReportCopyDone(t *testing.T) {
+	defer func(interval int64) { CopyProgressInterval = interval }(CopyProgressInterval)
+	CopyProgressInterval = 2
+
+	reported := make([]int64, 0)
+	progress := func(rowsCopied int64) {
+		reported = append(reported, rowsCopied)
+	}
+
+	// 4 was reported by copyRows already
+	reportCopyDone(progress, 4)
+	reportCopyDone(progress, 5)
+	reportCopyDone(progress, 0)
+	reportCopyDone(nil, 3)
+
+	if testx.CompareString("progress", "[5 0]", fmt.Sprint(reported), t) {
+		return
+	}
+}
+
+func TestCopyRowsColumnMismatch(t *testing.T) {
+	table := &testTable{name: "orders", columns: []string{"id", "name"}}
+	source := NewSliceCopySource([][]interface{}{{1, "a"}, {2}})
+
+	executed := 0
+	count, err := copyRows(table, source, nil, func(values []interface{}) error {
+		executed++
+		return nil
+	})
+	if err == nil {
+		t.Fatalf("Param err [Expected error, Actual: nil]")
+	}
+
+	if testx.CompareString("err", "row 1 has 1 values, table orders has 2 columns", err.Error(), t) {
+		return
+	}
+
+	if testx.CompareInt("count", 1, int(count), t) {
+		return
+	}
+
+	if testx.CompareInt("executed", 1, executed, t) {
+		return
+	}
+}
+
+func TestCopyRowsExecError(t *testing.T) {
+	table := &testTable{name: "orders", columns: []string{"id"}}
+	source := NewSliceCopySource([][]interface{}{{1}, {2}})
+
+	failure := errors.New("failed")
+	count, err := copyRows(table, source, nil, func(values []interface{}) error {
+		return failure
+	})
+	if err != failure {
+		t.Errorf("Param err [Expected %v, Actual: %v]", failure, err)
+	}
+
+	if testx.CompareInt("count", 0, int(count), t) {
+		return
+	}
+}
@@ -1,6 +1,9 @@
 package sqlx
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 type DatabaseCreator struct {
 	DatabaseName string
@@ -8,15 +11,40 @@ type DatabaseCreator struct {
 	User         string
 	Password     string
 	Host         string
-	Tables       map[string]SQLTable
+	Port         int
+	// ssl, timeouts and pool settings, connection parameters set on the creator take precedence
+	Options *ConnectionOptions
+	// waiting for the database on startup, DefaultRetryOptions if nil
+	Retry *RetryOptions
+	// additional schemas getting their own copy of Tables, e.g. one per tenant
+	TenantSchemas []string
+	Tables        map[string]SQLTable
+	Migrations    []*Migration
 }
 
-func (it *DatabaseCreator) dbinfo() string {
-	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s sslmode=disable", it.Host, it.User, it.Password, it.DatabaseName)
+func (it *DatabaseCreator) connectionOptions() *ConnectionOptions {
+	options := &ConnectionOptions{}
+	if it.Options != nil {
+		options = it.Options.WithDatabase(it.DatabaseName)
+	}
+
+	options.Host = it.Host
+	options.User = it.User
+	options.Password = it.Password
+	options.DBName = it.DatabaseName
+	options.Schema = it.Schema
+	if it.Port > 0 {
+		options.Port = it.Port
+	}
+
+	return options
 }
 
-func (it *DatabaseCreator) dbDefaultInfo() string {
-	return fmt.Sprintf("host=%s user=%s password=%s dbname=postgres sslmode=disable", it.Host, it.User, it.Password)
+// options for the maintenance database used to create the database
+func (it *DatabaseCreator) defaultConnectionOptions() *ConnectionOptions {
+	options := it.connectionOptions().WithDatabase("postgres")
+	options.Schema = ""
+	return options
 }
 
 func NewDatabaseCreator(databaseName string) *DatabaseCreator {
@@ -28,7 +56,34 @@ func NewDatabaseCreator(databaseName string) *DatabaseCreator {
 
 
 func NewDatabaseCreatorWithInfo(info *SqlDBInfo) *DatabaseCreator {
-	return NewDatabaseCreator(info.DBName).WithHost(info.Host).WithUser(info.User).WithPassword(info.Password)
+	return NewDatabaseCreatorWithOptions(info.Options())
+}
+
+func NewDatabaseCreatorWithOptions(options *ConnectionOptions) *DatabaseCreator {
+	creator := NewDatabaseCreator(options.DBName).WithHost(options.Host).WithUser(options.User).WithPassword(options.Password).WithConnectionOptions(options)
+	creator.Port = options.Port
+	if options.Schema != "" {
+		creator.WithSchema(options.Schema)
+	}
+	return creator
+}
+
+func (it *DatabaseCreator) WithConnectionOptions(options *ConnectionOptions) *DatabaseCreator {
+	it.Options = options
+	return it
+}
+
+/*
+	Backoff while waiting for the database to accept connections, use MaxAttempts 1 to fail immediately
+ */
+func (it *DatabaseCreator) WithRetry(options *RetryOptions) *DatabaseCreator {
+	it.Retry = options
+	return it
+}
+
+func (it *DatabaseCreator) WithPort(port int) *DatabaseCreator {
+	it.Port = port
+	return it
 }
 
 func (builder *DatabaseCreator) WithHost(host string) *DatabaseCreator {
@@ -41,6 +96,15 @@ func (it *DatabaseCreator) WithSchema(schema string) *DatabaseCreator {
 	return it
 }
 
+/*
+	Creates the tables in each of schemas as well and applies the migrations to each of them, statements
+	against a tenant need the tables of TablesInSchema(schema, creator.Tables)
+ */
+func (it *DatabaseCreator) WithTenantSchemas(schemas ...string) *DatabaseCreator {
+	it.TenantSchemas = append(it.TenantSchemas, schemas...)
+	return it
+}
+
 func (it *DatabaseCreator) WithUser(user string) *DatabaseCreator {
 	it.User = user
 	return it
@@ -58,7 +122,68 @@ func (it *DatabaseCreator) AddTable(table SQLTable) *DatabaseCreator {
 	return it
 }
 
+/*
+	Migrations are applied by OpenAndInitializeDB after the tables have been created. On a fresh schema
+	the tables are created from their current definitions and the migrations are only recorded as applied
+ */
+func (it *DatabaseCreator) WithMigrations(migrations ...*Migration) *DatabaseCreator {
+	it.Migrations = append(it.Migrations, migrations...)
+	return it
+}
+
+func (it *DatabaseCreator) WithMigrationsDir(dir string) (*DatabaseCreator, error) {
+	migrations, err := LoadMigrationsFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return it.WithMigrations(migrations...), nil
+}
+
+/*
+	Migrator of schema, the schema of the creator uses the search_path of the connection
+ */
+func (it *DatabaseCreator) migrator(db *SQLDB, schema string) *Migrator {
+	migrator := NewMigrator(db).WithMigrations(it.Migrations...)
+	if schema != it.Schema {
+		migrator.WithSchema(schema)
+	}
+	return migrator
+}
+
+/*
+	Creates the tables of schema and brings it to the latest migration. If none of the tables existed before,
+	they already match the latest definitions and the migrations are only recorded as applied
+ */
+func (it *DatabaseCreator) initializeSchema(ctx context.Context, db *SQLDB, schema string, forceRecreate bool) error {
+	fresh := false
+	if len(it.Migrations) > 0 && len(it.Tables) > 0 {
+		exists, err := anyTableExists(ctx, db.executor(), TablesInSchema(schema, it.Tables))
+		if err != nil {
+			return err
+		}
+		fresh = forceRecreate || !exists
+	}
+
+	err := db.InitializeDatabaseContext(ctx, it.DatabaseName, schema, it.Tables, forceRecreate)
+	if err != nil || len(it.Migrations) == 0 {
+		return err
+	}
+
+	migrator := it.migrator(db, schema)
+	if fresh {
+		_, err = migrator.Baseline(ctx)
+		return err
+	}
+
+	_, err = migrator.Up(ctx)
+	return err
+}
+
 func (it *DatabaseCreator) OpenAndInitializeDB(forceRecreate bool) (*SQLDB, error) {
+	return it.OpenAndInitializeDBContext(context.Background(), forceRecreate)
+}
+
+func (it *DatabaseCreator) OpenAndInitializeDBContext(ctx context.Context, forceRecreate bool) (*SQLDB, error) {
 	config := it
 
 	if config.Host == "" {
@@ -81,36 +206,58 @@ func (it *DatabaseCreator) OpenAndInitializeDB(forceRecreate bool) (*SQLDB, erro
 		return nil, fmt.Errorf("no password provided, use creator with .WithPassword(...)")
 	}
 
-	db, err := OpenSqlDB(it.dbDefaultInfo())
+	db, err := OpenSqlDBWithOptions(it.defaultConnectionOptions())
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.MaybeCreateDatabase(config.DatabaseName)
+	err = db.WaitForConnection(ctx, it.Retry)
 	if err != nil {
+		db.Connection.Close()
 		return nil, err
 	}
 
-	// reopen connection with correct database
-	err = db.Connection.Close()
+	err = db.MaybeCreateDatabaseContext(ctx, config.DatabaseName)
 	if err != nil {
+		db.Connection.Close()
 		return nil, err
 	}
 
-	db, err = OpenSqlDB(it.dbinfo())
+	// reopen connection with correct database
+	err = db.Connection.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.InitializeDatabase(config.DatabaseName, config.Schema, config.Tables, forceRecreate)
+	db, err = OpenSqlDBWithOptions(it.connectionOptions())
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Connection.Ping()
+	err = config.initializeDB(ctx, db, forceRecreate)
 	if err != nil {
+		db.Connection.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
+
+/*
+	Initializes the schema of the creator and all tenant schemas on the connection to the target database
+ */
+func (it *DatabaseCreator) initializeDB(ctx context.Context, db *SQLDB, forceRecreate bool) error {
+	err := db.WaitForConnection(ctx, it.Retry)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range append([]string{it.Schema}, it.TenantSchemas...) {
+		err = it.initializeSchema(ctx, db, v, forceRecreate)
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Connection.PingContext(ctx)
+}
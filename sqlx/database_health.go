@@ -0,0 +1,137 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+)
+
+/*
+	Exponential backoff used while waiting for the database, e.g. when postgres starts together with the service
+ */
+type RetryOptions struct {
+	// attempts including the first one, 1 disables retrying
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	// fraction of the delay added or subtracted randomly, e.g. 0.2 for +-20%
+	Jitter float64
+}
+
+func DefaultRetryOptions() *RetryOptions {
+	return &RetryOptions{
+		MaxAttempts:  10,
+		InitialDelay: 200 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+/*
+	Delay before the next attempt after attempt failed, random has to be in [0,1)
+ */
+func (it *RetryOptions) delay(attempt int, random float64) time.Duration {
+	delay := float64(it.InitialDelay) * math.Pow(it.Multiplier, float64(attempt))
+	if it.MaxDelay > 0 && delay > float64(it.MaxDelay) {
+		delay = float64(it.MaxDelay)
+	}
+
+	delay += delay * it.Jitter * (2*random - 1)
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+/*
+	Pings the database until it answers, retrying connection errors with exponential backoff.
+	Errors reported by a running server, e.g. failed authentication, are returned immediately
+ */
+func (it *SQLDB) WaitForConnection(ctx context.Context, options *RetryOptions) error {
+	if options == nil {
+		options = DefaultRetryOptions()
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = it.Connection.PingContext(ctx)
+		if err == nil || !isRetryableConnectError(err) || attempt+1 >= options.MaxAttempts {
+			return err
+		}
+
+		delay := options.delay(attempt, rand.Float64())
+		logMsg(fmt.Sprintf("connection attempt %v failed, retrying in %v: %v", attempt+1, delay, err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+/*
+	Network errors and postgres refusing connections while starting up, anything else like
+	a malformed DSN or missing certificates fails immediately
+ */
+func isRetryableConnectError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// includes *net.OpError, e.g. connection refused
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.EOF) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	// connection_exception and operator_intervention, e.g. cannot_connect_now
+	class := pqErr.Code.Class()
+	return class == "08" || class == "57"
+}
+
+//////////////////////////////////////
+//
+// Health
+//
+/////////////////////////////////////
+
+type HealthStatus struct {
+	Latency time.Duration `json:"latency_ns"`
+	Stats   sql.DBStats   `json:"stats"`
+}
+
+/*
+	Pings the database and reports the round trip time together with the pool statistics
+ */
+func (it *SQLDB) Health(ctx context.Context) (*HealthStatus, error) {
+	start := time.Now()
+	err := it.Connection.PingContext(ctx)
+	status := &HealthStatus{
+		Latency: time.Since(start),
+		Stats:   it.Connection.Stats(),
+	}
+
+	return status, err
+}
+
+/*
+	Health as generic check, e.g. for httpx.HealthcheckHandlerWith
+ */
+func (it *SQLDB) HealthCheck(ctx context.Context) (interface{}, error) {
+	return it.Health(ctx)
+}
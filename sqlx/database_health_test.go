@@ -0,0 +1,53 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+	"github.com/lib/pq"
+)
+
+func TestRetryOptionsDelay(t *testing.T) {
+	options := &RetryOptions{
+		MaxAttempts:  5,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     time.Second,
+		Multiplier:   2,
+		Jitter:       0.5,
+	}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for k, v := range expected {
+		if delay := options.delay(k, 0.5); delay != v {
+			t.Errorf("Param delay of attempt %v [Expected %v, Actual: %v]", k, v, delay)
+			return
+		}
+	}
+
+	if delay := options.delay(0, 0); delay != 50*time.Millisecond {
+		t.Errorf("Param jitter [Expected 50ms, Actual: %v]", delay)
+		return
+	}
+}
+
+func TestIsRetryableConnectError(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	if !isRetryableConnectError(refused) ||
+		!isRetryableConnectError(fmt.Errorf("connecting: %w", refused)) ||
+		!isRetryableConnectError(io.EOF) ||
+		!isRetryableConnectError(&pq.Error{Code: "57P03"}) ||
+		!isRetryableConnectError(&pq.Error{Code: "08006"}) {
+		t.Errorf("wrong classification of retryable connect errors")
+	}
+
+	if isRetryableConnectError(&pq.Error{Code: "28P01"}) ||
+		isRetryableConnectError(errors.New("missing \"=\" after \"host\" in connection info string")) ||
+		isRetryableConnectError(fmt.Errorf("ping: %w", context.DeadlineExceeded)) ||
+		isRetryableConnectError(context.Canceled) {
+		t.Errorf("wrong classification of permanent connect errors")
+	}
+}
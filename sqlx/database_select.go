@@ -0,0 +1,285 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+	Executes builder and scans all rows into dest, which has to be a pointer to a slice of structs
+	or of pointers to structs. Columns are matched to fields by their db tags
+ */
+func (pg *SQLDB) SelectInto(dest interface{}, builder Statement) error {
+	return pg.SelectIntoContext(context.Background(), dest, builder)
+}
+
+func (pg *SQLDB) SelectIntoContext(ctx context.Context, dest interface{}, builder Statement) error {
+	return selectInto(ctx, pg.executor(), dest, builder)
+}
+
+/*
+	Executes builder and scans the first row into dest, which has to be a pointer to a struct.
+	Returns ErrNotFound if there is no row
+ */
+func (pg *SQLDB) SelectOne(dest interface{}, builder Statement) error {
+	return pg.SelectOneContext(context.Background(), dest, builder)
+}
+
+func (pg *SQLDB) SelectOneContext(ctx context.Context, dest interface{}, builder Statement) error {
+	return selectOne(ctx, pg.executor(), dest, builder)
+}
+
+/*
+	Like SelectInto for a builder using Keyset pagination. Returns the cursor of the next page,
+	which is empty if the page is not full and so no further rows are expected
+ */
+func (pg *SQLDB) SelectKeyset(dest interface{}, builder *StatementBuilder) (string, error) {
+	return pg.SelectKeysetContext(context.Background(), dest, builder)
+}
+
+func (pg *SQLDB) SelectKeysetContext(ctx context.Context, dest interface{}, builder *StatementBuilder) (string, error) {
+	if builder.keyset == nil {
+		return "", fmt.Errorf("builder does not use keyset pagination")
+	}
+
+	err := selectInto(ctx, pg.executor(), dest, builder)
+	if err != nil {
+		return "", err
+	}
+
+	return nextCursor(dest, builder.keyset.columns, builder.limit)
+}
+
+/*
+	One page of a list and the total number of rows matching its conditions
+ */
+type Page struct {
+	Items  interface{} `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+/*
+	Scans the rows of builder into dest like SelectInto and counts all rows matching its conditions
+	using builder.CountStatement()
+ */
+func (pg *SQLDB) SelectPage(dest interface{}, builder *StatementBuilder) (*Page, error) {
+	return pg.SelectPageContext(context.Background(), dest, builder)
+}
+
+func (pg *SQLDB) SelectPageContext(ctx context.Context, dest interface{}, builder *StatementBuilder) (*Page, error) {
+	return selectPage(ctx, pg.executor(), dest, builder)
+}
+
+func (it *Tx) SelectPage(dest interface{}, builder *StatementBuilder) (*Page, error) {
+	return it.SelectPageContext(context.Background(), dest, builder)
+}
+
+func (it *Tx) SelectPageContext(ctx context.Context, dest interface{}, builder *StatementBuilder) (*Page, error) {
+	return selectPage(ctx, it.executor(), dest, builder)
+}
+
+func (it *Tx) SelectInto(dest interface{}, builder Statement) error {
+	return it.SelectIntoContext(context.Background(), dest, builder)
+}
+
+func (it *Tx) SelectIntoContext(ctx context.Context, dest interface{}, builder Statement) error {
+	return selectInto(ctx, it.executor(), dest, builder)
+}
+
+func (it *Tx) SelectOne(dest interface{}, builder Statement) error {
+	return it.SelectOneContext(context.Background(), dest, builder)
+}
+
+func (it *Tx) SelectOneContext(ctx context.Context, dest interface{}, builder Statement) error {
+	return selectOne(ctx, it.executor(), dest, builder)
+}
+
+func selectInto(ctx context.Context, ex executor, dest interface{}, builder Statement) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("expected pointer to slice, got %v", reflect.TypeOf(dest))
+	}
+
+	slice := destValue.Elem()
+	elementType := slice.Type().Elem()
+	isPointer := elementType.Kind() == reflect.Ptr
+	structType := elementType
+	if isPointer {
+		structType = elementType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("expected slice of structs, got %v", slice.Type())
+	}
+
+	rows, err := selectRows(ctx, ex, builder)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fields, err := columnFields(rows, structType)
+	if err != nil {
+		return err
+	}
+
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	for rows.Next() {
+		element := reflect.New(structType)
+		err = scanRow(rows, element.Elem(), fields)
+		if err != nil {
+			return err
+		}
+
+		if isPointer {
+			result = reflect.Append(result, element)
+		} else {
+			result = reflect.Append(result, element.Elem())
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	slice.Set(result)
+	return nil
+}
+
+func selectPage(ctx context.Context, ex executor, dest interface{}, builder *StatementBuilder) (*Page, error) {
+	err := selectInto(ctx, ex, dest, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	statement, params, err := builder.CountStatement().Build()
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := countByStatement(ctx, ex, statement, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Page{
+		Items:  reflect.ValueOf(dest).Elem().Interface(),
+		Total:  total,
+		Limit:  builder.limit,
+		Offset: builder.offset,
+	}, nil
+}
+
+func selectOne(ctx context.Context, ex executor, dest interface{}, builder Statement) error {
+	destValue := reflect.ValueOf(dest)
+	if destValue.Kind() != reflect.Ptr || destValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected pointer to struct, got %v", reflect.TypeOf(dest))
+	}
+
+	rows, err := selectRows(ctx, ex, builder)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fields, err := columnFields(rows, destValue.Elem().Type())
+	if err != nil {
+		return err
+	}
+
+	if !rows.Next() {
+		err = rows.Err()
+		if err != nil {
+			return err
+		}
+		return ErrNotFound
+	}
+
+	return scanRow(rows, destValue.Elem(), fields)
+}
+
+func nextCursor(dest interface{}, columns []string, limit int) (string, error) {
+	slice := reflect.ValueOf(dest).Elem()
+	if slice.Len() == 0 || (limit > 0 && slice.Len() < limit) {
+		return "", nil
+	}
+
+	last := reflect.Indirect(slice.Index(slice.Len() - 1))
+	fields, err := structFields(last.Type(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	byColumn := make(map[string][]int, len(fields))
+	for _, v := range fields {
+		byColumn[v.Column] = v.Index
+	}
+
+	values := make([]interface{}, len(columns))
+	for k, v := range columns {
+		// qualified keyset columns are matched by their column name
+		column := v[strings.LastIndex(v, ".")+1:]
+		index, ok := byColumn[column]
+		if !ok {
+			return "", fmt.Errorf("keyset column %v has no matching field in %v", v, last.Type())
+		}
+
+		value := last.FieldByIndex(index).Interface()
+		if valuer, ok := value.(driver.Valuer); ok {
+			value, err = valuer.Value()
+			if err != nil {
+				return "", err
+			}
+		}
+		values[k] = value
+	}
+
+	return EncodeCursor(values...)
+}
+
+/*
+	Maps every column of rows to the index of the struct field tagged with its name
+ */
+func columnFields(rows *sql.Rows, structType reflect.Type) ([]structField, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := structFields(structType, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byColumn := make(map[string]structField, len(fields))
+	for _, v := range fields {
+		byColumn[v.Column] = v
+	}
+
+	result := make([]structField, len(columns))
+	for k, column := range columns {
+		field, ok := byColumn[column]
+		if !ok {
+			return nil, fmt.Errorf("column %v has no matching field in %v", column, structType)
+		}
+		result[k] = field
+	}
+
+	return result, nil
+}
+
+func scanRow(rows *sql.Rows, target reflect.Value, fields []structField) error {
+	pointers := make([]interface{}, len(fields))
+	for k, v := range fields {
+		pointers[k] = v.scanTarget(target.FieldByIndex(v.Index))
+	}
+
+	return rows.Scan(pointers...)
+}
@@ -0,0 +1,129 @@
+package sqlx
+
+import (
+	"database/sql/driver"
+	"errors"
+	"github.com/ellsol/gox/testx"
+	"reflect"
+	"testing"
+)
+
+func TestSelectIntoDestValidation(t *testing.T) {
+	db, _ := openFakeDB(t)
+	builder := NewSelectStatement("*", "accounts")
+
+	var accounts []testAccount
+	var numbers []int
+	var account testAccount
+	invalid := map[string]interface{}{
+		"slice":             accounts,
+		"pointer to struct": &account,
+		"pointer to ints":   &numbers,
+		"nil":               nil,
+	}
+
+	for name, dest := range invalid {
+		if db.SelectInto(dest, builder) == nil {
+			t.Errorf("Param %v [Expected error, Actual: nil]", name)
+		}
+	}
+
+	if db.SelectOne(account, builder) == nil {
+		t.Errorf("Param struct [Expected error, Actual: nil]")
+	}
+
+	if db.SelectOne(&accounts, builder) == nil {
+		t.Errorf("Param pointer to slice [Expected error, Actual: nil]")
+	}
+}
+
+func TestSelectInto(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.columns = []string{"name", "id"}
+	state.rows = [][]driver.Value{{"max", int64(1)}, {"eva", int64(2)}}
+	builder := NewSelectStatement("name,id", "accounts")
+
+	var accounts []testAccount
+	err := db.SelectInto(&accounts, builder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("accounts", 2, len(accounts), t) {
+		return
+	}
+
+	if testx.CompareString("name", "eva", accounts[1].Name, t) {
+		return
+	}
+
+	if testx.CompareInt("id", 2, accounts[1].ID, t) {
+		return
+	}
+
+	var pointers []*testAccount
+	err = db.SelectInto(&pointers, builder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("pointers", 2, len(pointers), t) {
+		return
+	}
+
+	if testx.CompareString("pointer name", "max", pointers[0].Name, t) {
+		return
+	}
+}
+
+func TestSelectIntoUnknownColumn(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.columns = []string{"id", "unknown"}
+	state.rows = [][]driver.Value{{int64(1), "x"}}
+
+	var accounts []testAccount
+	err := db.SelectInto(&accounts, NewSelectStatement("*", "accounts"))
+	if err == nil {
+		t.Fatalf("Param err [Expected error, Actual: nil]")
+	}
+
+	if testx.CompareString("err", "column unknown has no matching field in sqlx.testAccount", err.Error(), t) {
+		return
+	}
+}
+
+func TestSelectOne(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.columns = []string{"id", "tags", "meta"}
+	state.rows = [][]driver.Value{{int64(4), []byte("{a,b}"), []byte(`{"k":"v"}`)}}
+
+	var document testDocument
+	err := db.SelectOne(&document, NewSelectStatement("id,tags,meta", "documents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("id", 4, document.ID, t) {
+		return
+	}
+
+	// array and json columns are scanned through scanTarget
+	if !reflect.DeepEqual(document.Tags, []string{"a", "b"}) {
+		t.Errorf("Param tags [Expected [a b], Actual: %v]", document.Tags)
+	}
+
+	if !reflect.DeepEqual(document.Meta, map[string]string{"k": "v"}) {
+		t.Errorf("Param meta [Expected map[k:v], Actual: %v]", document.Meta)
+	}
+}
+
+func TestSelectOneNotFound(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.columns = []string{"id"}
+
+	var account testAccount
+	err := db.SelectOne(&account, NewSelectStatement("id", "accounts"))
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Param err [Expected %v, Actual: %v]", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,95 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ellsol/gox/typex"
+)
+
+const (
+	UpsertStatement          = "INSERT INTO %v(%v) VALUES(%v) ON CONFLICT (%v) DO UPDATE SET %v;"
+	UpsertDoNothingStatement = "INSERT INTO %v(%v) VALUES(%v) ON CONFLICT (%v) DO NOTHING;"
+)
+
+var ErrNoConflictColumns = errors.New("upsert needs at least one conflict column")
+
+/*
+	Inserts values or, if a row with the same conflictColumns already exists, updates all other columns of it.
+	values have to be given in the order of table.ColumnNames()
+ */
+func (pg *SQLDB) Upsert(table SQLTable, conflictColumns []string, values []interface{}) error {
+	return pg.UpsertContext(context.Background(), table, conflictColumns, values)
+}
+
+func (pg *SQLDB) UpsertContext(ctx context.Context, table SQLTable, conflictColumns []string, values []interface{}) error {
+	if len(conflictColumns) == 0 {
+		return ErrNoConflictColumns
+	}
+
+	statement := CreateUpsertStatementFor(pg.dialect(), table, conflictColumns)
+	_, err := pg.executor().ExecContext(ctx, statement, values...)
+	return err
+}
+
+/*
+	Inserts values and silently skips rows which would violate conflictColumns.
+	Returns true if a row has been inserted
+ */
+func (pg *SQLDB) UpsertDoNothing(table SQLTable, conflictColumns []string, values []interface{}) (bool, error) {
+	return pg.UpsertDoNothingContext(context.Background(), table, conflictColumns, values)
+}
+
+func (pg *SQLDB) UpsertDoNothingContext(ctx context.Context, table SQLTable, conflictColumns []string, values []interface{}) (bool, error) {
+	if len(conflictColumns) == 0 {
+		return false, ErrNoConflictColumns
+	}
+
+	statement := CreateUpsertDoNothingStatementFor(pg.dialect(), table, conflictColumns)
+	result, err := pg.executor().ExecContext(ctx, statement, values...)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return count == 1, nil
+}
+
+/*
+	Maps SQLTable to
+	INSERT INTO table(tag1, tag2, tag3) VALUES($1,$2,$3) ON CONFLICT (tag1) DO UPDATE SET tag2 = EXCLUDED.tag2,tag3 = EXCLUDED.tag3;
+	If every column is part of the conflict target there is nothing to update and the DO NOTHING variant is returned
+ */
+func CreateUpsertStatement(table SQLTable, conflictColumns []string) string {
+	return CreateUpsertStatementFor(Postgres, table, conflictColumns)
+}
+
+/*
+	Maps SQLTable to
+	INSERT INTO table(tag1, tag2) VALUES($1,$2) ON CONFLICT (tag1) DO NOTHING;
+ */
+func CreateUpsertDoNothingStatement(table SQLTable, conflictColumns []string) string {
+	return CreateUpsertDoNothingStatementFor(Postgres, table, conflictColumns)
+}
+
+func CreateUpsertStatementFor(dialect Dialect, table SQLTable, conflictColumns []string) string {
+	updateColumns := typex.FilterStringList(table.ColumnNames(), func(tag string) bool {
+		return !typex.StringListContains(tag, conflictColumns)
+	})
+
+	return dialect.UpsertStatement(table.Name(), table.ColumnNames(), conflictColumns, updateColumns)
+}
+
+func CreateUpsertDoNothingStatementFor(dialect Dialect, table SQLTable, conflictColumns []string) string {
+	return dialect.UpsertStatement(table.Name(), table.ColumnNames(), conflictColumns, make([]string, 0))
+}
+
+func insertPlaceholders(columns []string) string {
+	return typex.CommaSeparatedString(typex.MapStringListWithPos(columns, func(key int, value string) string {
+		return fmt.Sprintf("$%v", key+1)
+	}))
+}
@@ -0,0 +1,72 @@
+package sqlx
+
+import (
+	"testing"
+	"github.com/ellsol/gox/testx"
+)
+
+type testTable struct {
+	name    string
+	columns []string
+}
+
+func (it *testTable) Name() string {
+	return it.name
+}
+
+func (it *testTable) ColumnNames() []string {
+	return it.columns
+}
+
+func (it *testTable) CreateStatement() string {
+	return ""
+}
+
+func TestCreateUpsertStatement(t *testing.T) {
+	table := &testTable{name: "accounts", columns: []string{"id", "name", "balance"}}
+
+	expected := "INSERT INTO accounts(id,name,balance) VALUES($1,$2,$3) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name,balance = EXCLUDED.balance;"
+	if testx.CompareString("upsert", expected, CreateUpsertStatement(table, []string{"id"}), t) {
+		return
+	}
+
+	expected = "INSERT INTO accounts(id,name,balance) VALUES($1,$2,$3) ON CONFLICT (id,name) DO UPDATE SET balance = EXCLUDED.balance;"
+	if testx.CompareString("upsert composite", expected, CreateUpsertStatement(table, []string{"id", "name"}), t) {
+		return
+	}
+}
+
+func TestCreateUpsertDoNothingStatement(t *testing.T) {
+	table := &testTable{name: "accounts", columns: []string{"id", "name"}}
+
+	expected := "INSERT INTO accounts(id,name) VALUES($1,$2) ON CONFLICT (id) DO NOTHING;"
+	if testx.CompareString("upsert do nothing", expected, CreateUpsertDoNothingStatement(table, []string{"id"}), t) {
+		return
+	}
+
+	// nothing left to update falls back to do nothing
+	if testx.CompareString("upsert all conflict", "INSERT INTO accounts(id,name) VALUES($1,$2) ON CONFLICT (id,name) DO NOTHING;", CreateUpsertStatement(table, []string{"id", "name"}), t) {
+		return
+	}
+}
+
+func TestUpsertWithoutConflictColumns(t *testing.T) {
+	db, state := openFakeDB(t)
+	table := &testTable{name: "accounts", columns: []string{"id", "name"}}
+
+	err := db.Upsert(table, nil, []interface{}{1, "a"})
+	if err != ErrNoConflictColumns {
+		t.Errorf("Param upsert error [Expected %v, Actual: %v]", ErrNoConflictColumns, err)
+		return
+	}
+
+	_, err = db.UpsertDoNothing(table, []string{}, []interface{}{1, "a"})
+	if err != ErrNoConflictColumns {
+		t.Errorf("Param upsert do nothing error [Expected %v, Actual: %v]", ErrNoConflictColumns, err)
+		return
+	}
+
+	if testx.CompareInt("statements", 0, len(state.statements), t) {
+		return
+	}
+}
@@ -0,0 +1,377 @@
+package sqlx
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ellsol/gox/typex"
+	"strconv"
+	"strings"
+)
+
+/*
+	Everything differing between the supported databases. Statements are generated with postgres style
+	$n placeholders and postgres column types, the dialect maps them when they are executed or created
+ */
+type Dialect interface {
+	Name() string
+	// driver name to pass to sql.Open, the driver itself has to be imported by the caller
+	DriverName() string
+	// placeholder for the bind parameter at position, starting with 1
+	Placeholder(position int) string
+	QuoteIdentifier(identifier string) string
+	// maps a column type as used by the table builders, e.g. SERIAL or BYTEA
+	ColumnType(columnType string) string
+	// insert statement updating updateColumns on conflict, doing nothing if updateColumns is empty
+	UpsertStatement(tableName string, columns []string, conflictColumns []string, updateColumns []string) string
+	// start of CREATE TABLE statements, with IF NOT EXISTS for drivers whose errors can not be classified
+	CreateTablePrefix() string
+	// most bind parameters a single statement may use
+	MaxStatementParams() int
+	// type of columns holding enum, postgres uses the type created by the TypeStatements of the table
+	EnumColumnType(enum *SQLEnum) string
+	// whether enums are created as types, otherwise TypeStatements of tables are skipped
+	SupportsEnumTypes() bool
+	// whether indexes are declared inside CREATE TABLE, otherwise they are created by IndexStatements
+	InlineIndexes() bool
+	SupportsSchemas() bool
+	SupportsDatabases() bool
+}
+
+var (
+	Postgres Dialect = &postgresDialect{}
+	SQLite   Dialect = &sqliteDialect{}
+	MySQL    Dialect = &mysqlDialect{}
+)
+
+/*
+	Rewrites the postgres style placeholders of statement for dialect. As placeholders of dialects like sqlite
+	are bound by their order of appearance, params are reordered (and duplicated) accordingly.
+	Placeholders inside quotes, line and block comments are left as they are, backslash escaped quotes are
+	recognized for mysql only, as sqlite treats backslashes literally
+ */
+func Rebind(dialect Dialect, statement string, params []interface{}) (string, []interface{}) {
+	if dialect == nil || dialect.Placeholder(1) == "$1" {
+		return statement, params
+	}
+
+	_, backslashEscapes := dialect.(*mysqlDialect)
+
+	var buffer bytes.Buffer
+	result := make([]interface{}, 0, len(params))
+	count := 0
+	var quote byte = 0
+
+	for i := 0; i < len(statement); i++ {
+		c := statement[i]
+
+		if quote != 0 {
+			if c == '\\' && backslashEscapes && i+1 < len(statement) {
+				buffer.WriteByte(c)
+				i++
+				buffer.WriteByte(statement[i])
+				continue
+			}
+
+			if c == quote {
+				quote = 0
+			}
+			buffer.WriteByte(c)
+			continue
+		}
+
+		if comment := commentEnd(statement, i); comment > i {
+			buffer.WriteString(statement[i:comment])
+			i = comment - 1
+			continue
+		}
+
+		if c == '\'' || c == '"' || c == '`' {
+			quote = c
+			buffer.WriteByte(c)
+			continue
+		}
+
+		if c == '$' && i+1 < len(statement) && isDigit(statement[i+1]) {
+			end := i + 1
+			for end < len(statement) && isDigit(statement[end]) {
+				end++
+			}
+
+			position, _ := strconv.Atoi(statement[i+1 : end])
+			count++
+			buffer.WriteString(dialect.Placeholder(count))
+			if position >= 1 && position <= len(params) {
+				result = append(result, params[position-1])
+			}
+
+			i = end - 1
+			continue
+		}
+
+		buffer.WriteByte(c)
+	}
+
+	// statement has already been rebound
+	if count == 0 {
+		return statement, params
+	}
+
+	return buffer.String(), result
+}
+
+// end of the comment starting at position, position if there is none
+func commentEnd(statement string, position int) int {
+	rest := statement[position:]
+	switch {
+	case strings.HasPrefix(rest, "--"):
+		end := strings.Index(rest, "\n")
+		if end < 0 {
+			return len(statement)
+		}
+		return position + end + 1
+	case strings.HasPrefix(rest, "/*"):
+		end := strings.Index(rest[2:], "*/")
+		if end < 0 {
+			return len(statement)
+		}
+		return position + 2 + end + 2
+	}
+	return position
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func upsertSet(updateColumns []string, format string) string {
+	return typex.CommaSeparatedString(typex.MapStringList(updateColumns, func(tag string) string {
+		return fmt.Sprintf(format, tag, tag)
+	}))
+}
+
+//////////////////////////////////////
+//
+// Postgres
+//
+/////////////////////////////////////
+
+type postgresDialect struct{}
+
+func (it *postgresDialect) Name() string {
+	return "postgres"
+}
+
+func (it *postgresDialect) DriverName() string {
+	return "postgres"
+}
+
+func (it *postgresDialect) Placeholder(position int) string {
+	return fmt.Sprintf("$%v", position)
+}
+
+func (it *postgresDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifier(identifier, `"`)
+}
+
+func (it *postgresDialect) ColumnType(columnType string) string {
+	return columnType
+}
+
+func (it *postgresDialect) UpsertStatement(tableName string, columns []string, conflictColumns []string, updateColumns []string) string {
+	if len(updateColumns) == 0 {
+		return fmt.Sprintf(UpsertDoNothingStatement, tableName, typex.CommaSeparatedString(columns), insertPlaceholders(columns), typex.CommaSeparatedString(conflictColumns))
+	}
+
+	return fmt.Sprintf(UpsertStatement, tableName, typex.CommaSeparatedString(columns), insertPlaceholders(columns), typex.CommaSeparatedString(conflictColumns), upsertSet(updateColumns, "%v = EXCLUDED.%v"))
+}
+
+// existing tables are recognized by ClassifyError
+func (it *postgresDialect) CreateTablePrefix() string {
+	return "CREATE TABLE "
+}
+
+func (it *postgresDialect) SupportsSchemas() bool {
+	return true
+}
+
+func (it *postgresDialect) MaxStatementParams() int {
+	return MaxStatementParams
+}
+
+func (it *postgresDialect) EnumColumnType(enum *SQLEnum) string {
+	return enum.TypeName
+}
+
+func (it *postgresDialect) SupportsEnumTypes() bool {
+	return true
+}
+
+func (it *postgresDialect) InlineIndexes() bool {
+	return false
+}
+
+func (it *postgresDialect) SupportsDatabases() bool {
+	return true
+}
+
+//////////////////////////////////////
+//
+// SQLite
+//
+/////////////////////////////////////
+
+type sqliteDialect struct{}
+
+func (it *sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (it *sqliteDialect) DriverName() string {
+	return "sqlite3"
+}
+
+func (it *sqliteDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (it *sqliteDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifier(identifier, `"`)
+}
+
+func (it *sqliteDialect) ColumnType(columnType string) string {
+	switch strings.ToUpper(columnType) {
+	// INTEGER PRIMARY KEY is an alias of the auto incremented rowid
+	case "SERIAL", "BIGSERIAL":
+		return "INTEGER"
+	case "BYTEA":
+		return "BLOB"
+	case "JSONB", "UUID", "TIMESTAMPTZ":
+		return "TEXT"
+	}
+	return columnType
+}
+
+func (it *sqliteDialect) UpsertStatement(tableName string, columns []string, conflictColumns []string, updateColumns []string) string {
+	// sqlite understands the postgres syntax since 3.24
+	return Postgres.UpsertStatement(tableName, columns, conflictColumns, updateColumns)
+}
+
+func (it *sqliteDialect) CreateTablePrefix() string {
+	return "CREATE TABLE IF NOT EXISTS "
+}
+
+func (it *sqliteDialect) SupportsSchemas() bool {
+	return false
+}
+
+// compile time default of sqlite before 3.32
+func (it *sqliteDialect) MaxStatementParams() int {
+	return 999
+}
+
+func (it *sqliteDialect) EnumColumnType(enum *SQLEnum) string {
+	return "TEXT"
+}
+
+func (it *sqliteDialect) SupportsEnumTypes() bool {
+	return false
+}
+
+func (it *sqliteDialect) InlineIndexes() bool {
+	return false
+}
+
+func (it *sqliteDialect) SupportsDatabases() bool {
+	return false
+}
+
+//////////////////////////////////////
+//
+// MySQL
+//
+/////////////////////////////////////
+
+type mysqlDialect struct{}
+
+func (it *mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (it *mysqlDialect) DriverName() string {
+	return "mysql"
+}
+
+func (it *mysqlDialect) Placeholder(position int) string {
+	return "?"
+}
+
+func (it *mysqlDialect) QuoteIdentifier(identifier string) string {
+	return quoteIdentifier(identifier, "`")
+}
+
+func (it *mysqlDialect) ColumnType(columnType string) string {
+	switch strings.ToUpper(columnType) {
+	case "SERIAL":
+		return "INT AUTO_INCREMENT"
+	case "BIGSERIAL":
+		return "BIGINT AUTO_INCREMENT"
+	case "BYTEA":
+		return "LONGBLOB"
+	case "JSONB":
+		return "JSON"
+	case "UUID":
+		return "CHAR(36)"
+	case "TIMESTAMPTZ":
+		return "DATETIME"
+	}
+	return columnType
+}
+
+func (it *mysqlDialect) UpsertStatement(tableName string, columns []string, conflictColumns []string, updateColumns []string) string {
+	// mysql resolves conflicts on any unique key, conflictColumns can not be specified
+	if len(updateColumns) == 0 {
+		return fmt.Sprintf("INSERT IGNORE INTO %v(%v) VALUES(%v);", tableName, typex.CommaSeparatedString(columns), insertPlaceholders(columns))
+	}
+
+	return fmt.Sprintf("INSERT INTO %v(%v) VALUES(%v) ON DUPLICATE KEY UPDATE %v;", tableName, typex.CommaSeparatedString(columns), insertPlaceholders(columns), upsertSet(updateColumns, "%v = VALUES(%v)"))
+}
+
+func (it *mysqlDialect) CreateTablePrefix() string {
+	return "CREATE TABLE IF NOT EXISTS "
+}
+
+func (it *mysqlDialect) SupportsSchemas() bool {
+	return false
+}
+
+func (it *mysqlDialect) MaxStatementParams() int {
+	return 65535
+}
+
+func (it *mysqlDialect) EnumColumnType(enum *SQLEnum) string {
+	return fmt.Sprintf("ENUM(%v)", strings.Join(enum.quotedValues(), ","))
+}
+
+func (it *mysqlDialect) SupportsEnumTypes() bool {
+	return false
+}
+
+// CREATE INDEX has no IF NOT EXISTS, indexes declared with the table are created once
+func (it *mysqlDialect) InlineIndexes() bool {
+	return true
+}
+
+func (it *mysqlDialect) SupportsDatabases() bool {
+	return true
+}
+
+/*
+	Quotes every part of a possibly qualified identifier like schema.table, doubling contained quotes
+ */
+func quoteIdentifier(identifier string, quote string) string {
+	parts := strings.Split(identifier, ".")
+	for k, v := range parts {
+		parts[k] = quote + strings.Replace(v, quote, quote+quote, -1) + quote
+	}
+	return strings.Join(parts, ".")
+}
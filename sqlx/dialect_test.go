@@ -0,0 +1,163 @@
+package sqlx
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"github.com/ellsol/gox/testx"
+)
+
+func TestRebind(t *testing.T) {
+	statement, params := Rebind(SQLite, "SELECT * FROM t WHERE a = $2 AND b = '$1' AND c = $1 OR d = $2", []interface{}{"one", "two"})
+
+	if testx.CompareString("statement", "SELECT * FROM t WHERE a = ? AND b = '$1' AND c = ? OR d = ?", statement, t) {
+		return
+	}
+
+	if len(params) != 3 || params[0] != "two" || params[1] != "one" || params[2] != "two" {
+		t.Errorf("params not reordered: %v", params)
+		return
+	}
+
+	statement, _ = Rebind(Postgres, "SELECT * FROM t WHERE a = $1", []interface{}{1})
+	if testx.CompareString("postgres statement", "SELECT * FROM t WHERE a = $1", statement, t) {
+		return
+	}
+}
+
+func TestRebindCommentsAndEscapes(t *testing.T) {
+	params := []interface{}{"one", "two"}
+
+	statement, result := Rebind(SQLite, "SELECT * FROM t -- not $1\nWHERE a = $2 /* nor $1 */ AND b = $1", params)
+	if testx.CompareString("comments", "SELECT * FROM t -- not $1\nWHERE a = ? /* nor $1 */ AND b = ?", statement, t) {
+		return
+	}
+
+	if len(result) != 2 || result[0] != "two" || result[1] != "one" {
+		t.Errorf("params not reordered: %v", result)
+		return
+	}
+
+	// the escaped quote does not end the string
+	statement, result = Rebind(MySQL, `SELECT * FROM t WHERE a = 'it\'s $1' AND b = $2`, params)
+	if testx.CompareString("mysql escape", `SELECT * FROM t WHERE a = 'it\'s $1' AND b = ?`, statement, t) {
+		return
+	}
+
+	if len(result) != 1 || result[0] != "two" {
+		t.Errorf("params not reordered: %v", result)
+		return
+	}
+
+	// sqlite has no backslash escapes, the string ends after the backslash
+	statement, _ = Rebind(SQLite, `SELECT * FROM t WHERE a = 'C:\' AND b = $1`, params)
+	if testx.CompareString("sqlite backslash", `SELECT * FROM t WHERE a = 'C:\' AND b = ?`, statement, t) {
+		return
+	}
+
+	statement, _ = Rebind(SQLite, "SELECT * FROM t WHERE a = 'it''s $1' AND b = $1", params)
+	if testx.CompareString("doubled quote", "SELECT * FROM t WHERE a = 'it''s $1' AND b = ?", statement, t) {
+		return
+	}
+}
+
+func TestDialectStatements(t *testing.T) {
+	definition := NewSQLTableBuilder("accounts").
+		WithSerialColumn("id", NotNull, IsPrimary).
+		WithByteAColumn("data").
+		Build()
+
+	if testx.CompareString("sqlite create", "CREATE TABLE IF NOT EXISTS accounts(id INTEGER PRIMARY KEY NOT NULL,data BLOB);", definition.CreateStatementFor(SQLite), t) {
+		return
+	}
+
+	if testx.CompareString("mysql create", "CREATE TABLE IF NOT EXISTS accounts(id INT AUTO_INCREMENT PRIMARY KEY NOT NULL,data LONGBLOB);", definition.CreateStatementFor(MySQL), t) {
+		return
+	}
+
+	table := &testTable{name: "accounts", columns: []string{"id", "name"}}
+	if testx.CompareString("mysql upsert", "INSERT INTO accounts(id,name) VALUES($1,$2) ON DUPLICATE KEY UPDATE name = VALUES(name);", CreateUpsertStatementFor(MySQL, table, []string{"id"}), t) {
+		return
+	}
+
+	statement, _ := NewSelectStatement("*", "accounts").AddEqualCondition("id", 1).WithDialect(MySQL).GetStatementAndParams()
+	if testx.CompareString("mysql select", "SELECT * FROM accounts WHERE id = ?", statement, t) {
+		return
+	}
+
+	if testx.CompareString("mysql quote", "`schema`.`ta``ble`", MySQL.QuoteIdentifier("schema.ta`ble"), t) {
+		return
+	}
+}
+
+func TestDialectCreateTableTwice(t *testing.T) {
+	definition := NewSQLTableBuilder("accounts").
+		WithSerialColumn("id", NotNull, IsPrimary).
+		Build()
+
+	// sqlite and mysql errors are not classified, repeated creation has to be a no-op in the statement itself
+	for _, dialect := range []Dialect{SQLite, MySQL} {
+		statement := createTableStatement(dialect, definition)
+		if !strings.HasPrefix(statement, "CREATE TABLE IF NOT EXISTS accounts(") {
+			t.Errorf("Param %v create [Expected CREATE TABLE IF NOT EXISTS, Actual: %v]", dialect.Name(), statement)
+			return
+		}
+	}
+}
+
+func TestDialectEnumsAndIndexes(t *testing.T) {
+	definition := NewSQLTableBuilder("orders").
+		WithSerialColumn("id", NotNull, IsPrimary).
+		WithEnumColumn("state", NewSQLEnum("order_state", "open", "it's done")).
+		WithIndex("orders_state", "state").
+		Build()
+
+	if testx.CompareString("postgres create", "CREATE TABLE orders(id SERIAL PRIMARY KEY NOT NULL,state order_state);", definition.CreateStatementFor(Postgres), t) {
+		return
+	}
+
+	if testx.CompareString("sqlite create", "CREATE TABLE IF NOT EXISTS orders(id INTEGER PRIMARY KEY NOT NULL,state TEXT);", definition.CreateStatementFor(SQLite), t) {
+		return
+	}
+
+	if testx.CompareString("mysql create", "CREATE TABLE IF NOT EXISTS orders(id INT AUTO_INCREMENT PRIMARY KEY NOT NULL,state ENUM('open','it''s done'),INDEX orders_state (state));", definition.CreateStatementFor(MySQL), t) {
+		return
+	}
+
+	for _, dialect := range []Dialect{Postgres, SQLite, MySQL} {
+		db, state := openFakeDB(t)
+		db.Dialect = dialect
+
+		err := db.MaybeCreateTableContext(context.Background(), definition)
+		if err != nil {
+			t.Errorf("Param %v error [Expected nil, Actual: %v]", dialect.Name(), err)
+			return
+		}
+
+		types, indexes := 0, 0
+		for _, v := range state.statements {
+			if strings.HasPrefix(v, "CREATE TYPE") {
+				types++
+			}
+			if strings.HasPrefix(v, "CREATE INDEX") {
+				indexes++
+			}
+		}
+
+		expectedTypes, expectedIndexes := 0, 1
+		if dialect == Postgres {
+			expectedTypes = 1
+		}
+		if dialect == MySQL {
+			expectedIndexes = 0
+		}
+
+		if testx.CompareInt(dialect.Name()+" types", expectedTypes, types, t) {
+			return
+		}
+
+		if testx.CompareInt(dialect.Name()+" indexes", expectedIndexes, indexes, t) {
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package sqlx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+const (
+	UniqueViolationCode     = "23505"
+	ForeignKeyViolationCode = "23503"
+	DuplicateDatabaseCode   = "42P04"
+	DuplicateSchemaCode     = "42P06"
+	DuplicateTableCode      = "42P07"
+	DuplicateObjectCode     = "42710"
+)
+
+/*
+	Kinds of database errors, to be checked with errors.Is. Details like the violated
+	constraint are available with errors.As and *DatabaseError
+ */
+var (
+	// returned by SelectOne if the statement did not return any row
+	ErrNotFound            = errors.New("no rows found")
+	ErrUniqueViolation     = errors.New("unique violation")
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	ErrAlreadyExists       = errors.New("already exists")
+	ErrSerialization       = errors.New("serialization failure")
+)
+
+/*
+	Driver error classified as one of the Err kinds, unwraps to the driver error, e.g. *pq.Error
+ */
+type DatabaseError struct {
+	Kind       error
+	Constraint string
+	Table      string
+	Err        error
+}
+
+func (it *DatabaseError) Error() string {
+	if it.Constraint != "" {
+		return fmt.Sprintf("%v (constraint %v): %v", it.Kind, it.Constraint, it.Err)
+	}
+	return fmt.Sprintf("%v: %v", it.Kind, it.Err)
+}
+
+func (it *DatabaseError) Is(target error) bool {
+	return it.Kind == target
+}
+
+func (it *DatabaseError) Unwrap() error {
+	return it.Err
+}
+
+/*
+	Wraps known driver errors into a *DatabaseError, other errors are returned unchanged
+ */
+func ClassifyError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var databaseError *DatabaseError
+	if errors.As(err, &databaseError) {
+		return err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return &DatabaseError{Kind: ErrNotFound, Err: err}
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var kind error
+	switch pqErr.Code {
+	case UniqueViolationCode:
+		kind = ErrUniqueViolation
+	case ForeignKeyViolationCode:
+		kind = ErrForeignKeyViolation
+	case DuplicateDatabaseCode, DuplicateSchemaCode, DuplicateTableCode, DuplicateObjectCode:
+		kind = ErrAlreadyExists
+	case SerializationFailureCode:
+		kind = ErrSerialization
+	default:
+		return err
+	}
+
+	return &DatabaseError{
+		Kind:       kind,
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+		Err:        err,
+	}
+}
+
+// nil if err reports that the created object already exists
+func ignoreAlreadyExists(err error) error {
+	if errors.Is(ClassifyError(err), ErrAlreadyExists) {
+		return nil
+	}
+	return err
+}
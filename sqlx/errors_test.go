@@ -0,0 +1,70 @@
+package sqlx
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"github.com/ellsol/gox/testx"
+	"github.com/lib/pq"
+)
+
+func TestClassifyError(t *testing.T) {
+	err := ClassifyError(&pq.Error{Code: UniqueViolationCode, Constraint: "accounts_name_key", Table: "accounts"})
+	if !errors.Is(err, ErrUniqueViolation) || errors.Is(err, ErrForeignKeyViolation) {
+		t.Errorf("expected unique violation, got %v", err)
+		return
+	}
+
+	var databaseError *DatabaseError
+	if !errors.As(err, &databaseError) {
+		t.Errorf("expected DatabaseError, got %v", err)
+		return
+	}
+
+	if testx.CompareString("constraint", "accounts_name_key", databaseError.Constraint, t) ||
+		testx.CompareString("table", "accounts", databaseError.Table, t) {
+		return
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		t.Errorf("expected DatabaseError to unwrap to the driver error")
+		return
+	}
+
+	cases := map[string]error{
+		ForeignKeyViolationCode:  ErrForeignKeyViolation,
+		DuplicateTableCode:       ErrAlreadyExists,
+		DuplicateSchemaCode:      ErrAlreadyExists,
+		DuplicateDatabaseCode:    ErrAlreadyExists,
+		SerializationFailureCode: ErrSerialization,
+	}
+	for code, kind := range cases {
+		if !errors.Is(ClassifyError(&pq.Error{Code: pq.ErrorCode(code)}), kind) {
+			t.Errorf("Param code %v [Expected %v]", code, kind)
+			return
+		}
+	}
+
+	if !errors.Is(ClassifyError(sql.ErrNoRows), ErrNotFound) {
+		t.Errorf("expected sql.ErrNoRows to be classified as ErrNotFound")
+		return
+	}
+
+	other := errors.New("connection refused")
+	if ClassifyError(other) != other || ClassifyError(nil) != nil {
+		t.Errorf("expected unknown errors to be returned unchanged")
+		return
+	}
+
+	wrapped := fmt.Errorf("transaction failed: %w", &pq.Error{Code: SerializationFailureCode})
+	if !IsSerializationFailure(wrapped) {
+		t.Errorf("expected wrapped serialization failure to be detected")
+		return
+	}
+
+	if ignoreAlreadyExists(&pq.Error{Code: DuplicateObjectCode}) != nil || ignoreAlreadyExists(other) != other {
+		t.Errorf("expected only already exists errors to be ignored")
+	}
+}
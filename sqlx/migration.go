@@ -0,0 +1,489 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MigrationsTableName = "schema_migrations"
+
+	CreateMigrationsTableStatement = "CREATE TABLE IF NOT EXISTS %v(version BIGINT PRIMARY KEY NOT NULL,name TEXT NOT NULL,applied_at BIGINT NOT NULL);"
+	MigrationsTableExistsStatement = "SELECT to_regclass($1) IS NOT NULL;"
+	SelectMigrationsStatement      = "SELECT version FROM %v ORDER BY version;"
+	InsertMigrationStatement       = "INSERT INTO %v(version,name,applied_at) VALUES($1,$2,$3);"
+	DeleteMigrationStatement       = "DELETE FROM %v WHERE version = $1;"
+	AdvisoryLockStatement          = "SELECT pg_advisory_lock($1);"
+	AdvisoryUnlockStatement        = "SELECT pg_advisory_unlock($1);"
+	SetSearchPathStatement         = "SET search_path TO %v, public;"
+	ResetSearchPathStatement       = "RESET search_path;"
+
+	MigrationUpSuffix   = ".up.sql"
+	MigrationDownSuffix = ".down.sql"
+)
+
+// key of the postgres advisory lock held while migrations are applied
+var MigrationLockKey int64 = 7283610934
+
+/*
+	A single schema change. Either Up/Down hold the sql or UpFunc/DownFunc run the change in go,
+	if both are set the func is used
+ */
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	UpFunc   func(ctx context.Context, tx *Tx) error
+	DownFunc func(ctx context.Context, tx *Tx) error
+}
+
+type Migrator struct {
+	db         *SQLDB
+	migrations []*Migration
+	dryRun     bool
+	output     io.Writer
+	// empty to use the search_path of the connection
+	schema string
+}
+
+func NewMigrator(db *SQLDB) *Migrator {
+	return &Migrator{
+		db:         db,
+		migrations: make([]*Migration, 0),
+		output:     os.Stdout,
+	}
+}
+
+func (it *Migrator) WithMigrations(migrations ...*Migration) *Migrator {
+	it.migrations = append(it.migrations, migrations...)
+	return it
+}
+
+/*
+	Loads all migrations of dir, see LoadMigrationsFromDir
+ */
+func (it *Migrator) WithMigrationsDir(dir string) (*Migrator, error) {
+	migrations, err := LoadMigrationsFromDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return it.WithMigrations(migrations...), nil
+}
+
+/*
+	Applies the migrations inside schema, e.g. a tenant schema. The search_path of the migration connection
+	is set to schema and the applied versions are kept in schema's own migrations table
+ */
+func (it *Migrator) WithSchema(schema string) *Migrator {
+	it.schema = schema
+	return it
+}
+
+func (it *Migrator) migrationsTable() string {
+	return QualifiedName(it.schema, MigrationsTableName)
+}
+
+/*
+	In dry run mode the statements are printed to output instead of being executed
+ */
+func (it *Migrator) WithDryRun(dryRun bool, output io.Writer) *Migrator {
+	it.dryRun = dryRun
+	if output != nil {
+		it.output = output
+	}
+	return it
+}
+
+/*
+	Registered migrations not applied yet, ordered by version
+ */
+func (it *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	migrations, err := it.sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := it.appliedVersions(ctx, it.db.Connection)
+	if err != nil {
+		return nil, err
+	}
+
+	return pendingMigrations(migrations, applied), nil
+}
+
+/*
+	Applies all pending migrations in version order, each one in its own transaction.
+	Returns the applied migrations
+ */
+func (it *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	migrations, err := it.sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if it.dryRun {
+		applied, err := it.appliedVersions(ctx, it.db.Connection)
+		if err != nil {
+			return nil, err
+		}
+
+		pending := pendingMigrations(migrations, applied)
+		for _, v := range pending {
+			it.print(v, v.Up, v.UpFunc != nil, "up")
+		}
+		return pending, nil
+	}
+
+	result := make([]*Migration, 0)
+	err = it.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := it.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range pendingMigrations(migrations, applied) {
+			logMsg(fmt.Sprintf("applying migration %v %v", v.Version, v.Name))
+			err = it.apply(ctx, conn, v, v.Up, v.UpFunc, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf(InsertMigrationStatement, it.migrationsTable()), v.Version, v.Name, time.Now().Unix())
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %v %v failed: %v", v.Version, v.Name, err)
+			}
+			result = append(result, v)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+/*
+	Reverts the last steps applied migrations, newest first. Returns the reverted migrations
+ */
+func (it *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	migrations, err := it.sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if it.dryRun {
+		applied, err := it.appliedVersions(ctx, it.db.Connection)
+		if err != nil {
+			return nil, err
+		}
+
+		reverting, err := revertMigrations(migrations, applied, steps)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range reverting {
+			it.print(v, v.Down, v.DownFunc != nil, "down")
+		}
+		return reverting, nil
+	}
+
+	result := make([]*Migration, 0)
+	err = it.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := it.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		reverting, err := revertMigrations(migrations, applied, steps)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range reverting {
+			logMsg(fmt.Sprintf("reverting migration %v %v", v.Version, v.Name))
+			err = it.apply(ctx, conn, v, v.Down, v.DownFunc, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, fmt.Sprintf(DeleteMigrationStatement, it.migrationsTable()), v.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %v %v failed: %v", v.Version, v.Name, err)
+			}
+			result = append(result, v)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+/*
+	Records all registered migrations as applied without running them, for schemas whose tables were just
+	created from definitions already containing every change. Returns the recorded migrations
+ */
+func (it *Migrator) Baseline(ctx context.Context) ([]*Migration, error) {
+	migrations, err := it.sortedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Migration, 0)
+	err = it.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := it.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range pendingMigrations(migrations, applied) {
+			_, err = conn.ExecContext(ctx, fmt.Sprintf(InsertMigrationStatement, it.migrationsTable()), v.Version, v.Name, time.Now().Unix())
+			if err != nil {
+				return err
+			}
+			result = append(result, v)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+func (it *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, statement string, fn func(ctx context.Context, tx *Tx) error, bookkeeping func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if fn != nil {
+		err = fn(ctx, &Tx{Connection: tx, Dialect: it.db.Dialect})
+	} else if strings.TrimSpace(statement) != "" {
+		_, err = tx.ExecContext(ctx, statement)
+	}
+
+	if err == nil {
+		err = bookkeeping(tx)
+	}
+
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+/*
+	Runs fn on a single connection holding the migration advisory lock, so concurrent starts apply migrations once
+ */
+func (it *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := it.db.Connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, AdvisoryLockStatement, MigrationLockKey)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), AdvisoryUnlockStatement, MigrationLockKey)
+
+	if it.schema != "" {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf(SetSearchPathStatement, pq.QuoteIdentifier(it.schema)))
+		if err != nil {
+			return err
+		}
+		// the connection goes back to the pool
+		defer conn.ExecContext(context.Background(), ResetSearchPathStatement)
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(CreateMigrationsTableStatement, it.migrationsTable()))
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (it *Migrator) appliedVersions(ctx context.Context, ex executor) (map[int64]bool, error) {
+	result := make(map[int64]bool)
+
+	// nothing applied yet if the bookkeeping table does not exist
+	var exists bool
+	err := ex.QueryRowContext(ctx, MigrationsTableExistsStatement, it.migrationsTable()).Scan(&exists)
+	if err != nil || !exists {
+		return result, err
+	}
+
+	rows, err := ex.QueryContext(ctx, fmt.Sprintf(SelectMigrationsStatement, it.migrationsTable()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		err = rows.Scan(&version)
+		if err != nil {
+			return nil, err
+		}
+		result[version] = true
+	}
+
+	return result, rows.Err()
+}
+
+func (it *Migrator) print(migration *Migration, statement string, isFunc bool, direction string) {
+	fmt.Fprintf(it.output, "-- %v %v (%v)\n", migration.Version, migration.Name, direction)
+	if it.schema != "" {
+		fmt.Fprintf(it.output, "-- in schema %v\n", it.schema)
+	}
+	if isFunc {
+		fmt.Fprintln(it.output, "-- go migration, no sql available")
+		return
+	}
+	fmt.Fprintln(it.output, strings.TrimSpace(statement))
+}
+
+func (it *Migrator) sortedMigrations() ([]*Migration, error) {
+	if it.db != nil && it.db.dialect() != Postgres {
+		return nil, fmt.Errorf("migrations are not supported by %v", it.db.dialect().Name())
+	}
+
+	result := make([]*Migration, len(it.migrations))
+	copy(result, it.migrations)
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	for k := 1; k < len(result); k++ {
+		if result[k].Version == result[k-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %v", result[k].Version)
+		}
+	}
+
+	return result, nil
+}
+
+func pendingMigrations(sorted []*Migration, applied map[int64]bool) []*Migration {
+	result := make([]*Migration, 0)
+	for _, v := range sorted {
+		if !applied[v.Version] {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func revertMigrations(sorted []*Migration, applied map[int64]bool, steps int) ([]*Migration, error) {
+	byVersion := make(map[int64]*Migration, len(sorted))
+	for _, v := range sorted {
+		byVersion[v.Version] = v
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for k := range applied {
+		versions = append(versions, k)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+
+	result := make([]*Migration, 0)
+	for _, version := range versions {
+		if len(result) >= steps {
+			break
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("applied migration %v is not registered", version)
+		}
+
+		// removing the bookkeeping row alone would leave the change in place
+		if migration.DownFunc == nil && strings.TrimSpace(migration.Down) == "" {
+			return nil, fmt.Errorf("migration %v %v can not be reverted, it has no down migration", migration.Version, migration.Name)
+		}
+		result = append(result, migration)
+	}
+
+	return result, nil
+}
+
+/*
+	Loads migrations from files named <version>_<name>.up.sql and <version>_<name>.down.sql, e.g.
+	0001_create_users.up.sql. The down file is optional
+ */
+func LoadMigrationsFromDir(dir string) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, v := range files {
+		if v.IsDir() {
+			continue
+		}
+
+		filename := v.Name()
+		isUp := strings.HasSuffix(filename, MigrationUpSuffix)
+		isDown := strings.HasSuffix(filename, MigrationDownSuffix)
+		if !isUp && !isDown {
+			continue
+		}
+
+		base := strings.TrimSuffix(strings.TrimSuffix(filename, MigrationUpSuffix), MigrationDownSuffix)
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %v does not start with a version: %v", filename, err)
+		}
+
+		name := ""
+		if len(parts) > 1 {
+			name = parts[1]
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{
+				Version: version,
+				Name:    name,
+			}
+			byVersion[version] = migration
+		}
+
+		if isUp {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	result := make([]*Migration, 0, len(byVersion))
+	for _, v := range byVersion {
+		if v.Up == "" {
+			return nil, fmt.Errorf("migration %v %v has no %v file", v.Version, v.Name, MigrationUpSuffix)
+		}
+		result = append(result, v)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
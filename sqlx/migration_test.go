@@ -0,0 +1,335 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/ellsol/gox/testx"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLoadMigrationsFromDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "migrations")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"0002_add_email.up.sql":    "ALTER TABLE users ADD COLUMN email TEXT;",
+		"0002_add_email.down.sql":  "ALTER TABLE users DROP COLUMN email;",
+		"0001_create_users.up.sql": "CREATE TABLE users(id SERIAL PRIMARY KEY);",
+		"README.md":                "ignored",
+	}
+
+	for k, v := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, k), []byte(v), 0644)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	migrations, err := LoadMigrationsFromDir(dir)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareInt("migrations", 2, len(migrations), t) {
+		return
+	}
+
+	if testx.CompareInt64("first version", 1, migrations[0].Version, t) ||
+		testx.CompareString("first name", "create_users", migrations[0].Name, t) ||
+		testx.CompareString("first down", "", migrations[0].Down, t) ||
+		testx.CompareString("second down", "ALTER TABLE users DROP COLUMN email;", migrations[1].Down, t) {
+		return
+	}
+}
+
+func TestPendingAndRevertMigrations(t *testing.T) {
+	migrations := []*Migration{{Version: 1}, {Version: 2, Down: "DROP TABLE users;"}, {Version: 3}}
+	applied := map[int64]bool{1: true, 2: true}
+
+	pending := pendingMigrations(migrations, applied)
+	if len(pending) != 1 || pending[0].Version != 3 {
+		t.Errorf("wrong pending migrations: %v", pending)
+		return
+	}
+
+	reverting, err := revertMigrations(migrations, applied, 1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(reverting) != 1 || reverting[0].Version != 2 {
+		t.Errorf("wrong migrations to revert: %v", reverting)
+		return
+	}
+
+	_, err = revertMigrations(migrations, applied, 2)
+	if err == nil {
+		t.Errorf("expected error for migration without down migration")
+		return
+	}
+
+	_, err = NewMigrator(nil).WithMigrations(&Migration{Version: 1}, &Migration{Version: 1}).sortedMigrations()
+	if err == nil {
+		t.Errorf("expected error for duplicate versions")
+	}
+}
+
+func TestCreatorMigrator(t *testing.T) {
+	creator := NewDatabaseCreator("app").
+		WithSchema("app").
+		WithTenantSchemas("tenant_a").
+		WithMigrations(&Migration{Version: 1, Name: "init", Up: "CREATE TABLE accounts(id SERIAL);"})
+
+	if testx.CompareString("migrations table", "schema_migrations", creator.migrator(nil, "app").migrationsTable(), t) ||
+		testx.CompareString("tenant migrations table", "tenant_a.schema_migrations", creator.migrator(nil, "tenant_a").migrationsTable(), t) ||
+		testx.CompareInt("migrations", 1, len(creator.migrator(nil, "tenant_a").migrations), t) {
+		return
+	}
+}
+
+//////////////////////////////////////
+//
+// Migrator against the fake driver
+//
+/////////////////////////////////////
+
+// tables and applied versions as seen by the statements of the migrator
+type fakeMigrationDatabase struct {
+	tables  map[string]bool
+	applied map[int64]bool
+	// statement failing when executed
+	failing string
+}
+
+func newFakeMigrationDatabase(state *fakeState) *fakeMigrationDatabase {
+	database := &fakeMigrationDatabase{
+		tables:  make(map[string]bool),
+		applied: make(map[int64]bool),
+	}
+
+	state.onExec = func(query string, args []driver.Value) error {
+		switch {
+		case query == database.failing:
+			return errors.New("failing statement")
+		case strings.HasPrefix(query, "CREATE TABLE "):
+			name := strings.TrimPrefix(strings.TrimPrefix(query, "CREATE TABLE "), "IF NOT EXISTS ")
+			database.tables[strings.TrimSpace(name[:strings.IndexAny(name, "( ")])] = true
+		case strings.HasPrefix(query, "INSERT INTO") && strings.Contains(query, MigrationsTableName):
+			database.applied[args[0].(int64)] = true
+		case strings.HasPrefix(query, "DELETE FROM") && strings.Contains(query, MigrationsTableName):
+			delete(database.applied, args[0].(int64))
+		}
+		return nil
+	}
+
+	state.onQuery = func(query string, args []driver.Value) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "to_regclass") {
+			return []string{"exists"}, [][]driver.Value{{database.tables[args[0].(string)]}}
+		}
+
+		versions := make([]int64, 0, len(database.applied))
+		for k := range database.applied {
+			versions = append(versions, k)
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+		rows := make([][]driver.Value, len(versions))
+		for k, v := range versions {
+			rows[k] = []driver.Value{v}
+		}
+		return []string{"version"}, rows
+	}
+
+	return database
+}
+
+func testMigrations() []*Migration {
+	return []*Migration{
+		{Version: 1, Name: "create_accounts", Up: "CREATE TABLE accounts(id SERIAL);", Down: "DROP TABLE accounts;"},
+		{Version: 2, Name: "add_email", Up: "ALTER TABLE accounts ADD COLUMN email TEXT;", Down: "ALTER TABLE accounts DROP COLUMN email;"},
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, state := openFakeDB(t)
+	database := newFakeMigrationDatabase(state)
+
+	applied, err := NewMigrator(db).WithMigrations(testMigrations()...).Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("applied", 2, len(applied), t) ||
+		testx.CompareInt("commits", 2, state.commits, t) {
+		return
+	}
+
+	expected := []string{
+		AdvisoryLockStatement,
+		"CREATE TABLE IF NOT EXISTS schema_migrations(version BIGINT PRIMARY KEY NOT NULL,name TEXT NOT NULL,applied_at BIGINT NOT NULL);",
+		MigrationsTableExistsStatement,
+		"SELECT version FROM schema_migrations ORDER BY version;",
+		"CREATE TABLE accounts(id SERIAL);",
+		"INSERT INTO schema_migrations(version,name,applied_at) VALUES($1,$2,$3);",
+		"ALTER TABLE accounts ADD COLUMN email TEXT;",
+		"INSERT INTO schema_migrations(version,name,applied_at) VALUES($1,$2,$3);",
+		AdvisoryUnlockStatement,
+	}
+	if testx.CompareString("statements", strings.Join(expected, "\n"), strings.Join(state.statements, "\n"), t) {
+		return
+	}
+
+	// applied migrations are skipped
+	applied, err = NewMigrator(db).WithMigrations(testMigrations()...).Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("applied again", 0, len(applied), t) || testx.CompareInt("recorded", 2, len(database.applied), t) {
+		return
+	}
+}
+
+func TestMigratorUpFailure(t *testing.T) {
+	db, state := openFakeDB(t)
+	database := newFakeMigrationDatabase(state)
+	database.failing = "ALTER TABLE accounts ADD COLUMN email TEXT;"
+
+	applied, err := NewMigrator(db).WithMigrations(testMigrations()...).Up(context.Background())
+	if err == nil {
+		t.Fatalf("Param err [Expected error, Actual: nil]")
+	}
+
+	if testx.CompareString("err", "migration 2 add_email failed: failing statement", err.Error(), t) {
+		return
+	}
+
+	// the failing migration is rolled back, the one before stays applied
+	if testx.CompareInt("applied", 1, len(applied), t) ||
+		testx.CompareInt("commits", 1, state.commits, t) ||
+		testx.CompareInt("rollbacks", 1, state.rollbacks, t) ||
+		testx.CompareInt("recorded", 1, len(database.applied), t) {
+		return
+	}
+
+	if state.statements[len(state.statements)-1] != AdvisoryUnlockStatement {
+		t.Errorf("Param last statement [Expected %v, Actual: %v]", AdvisoryUnlockStatement, state.statements[len(state.statements)-1])
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	db, state := openFakeDB(t)
+	database := newFakeMigrationDatabase(state)
+	database.applied[1] = true
+	database.applied[2] = true
+
+	reverted, err := NewMigrator(db).WithMigrations(testMigrations()...).Down(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("reverted", 1, len(reverted), t) ||
+		testx.CompareInt64("version", 2, reverted[0].Version, t) {
+		return
+	}
+
+	if !database.applied[1] || database.applied[2] {
+		t.Errorf("Param applied [Expected map[1:true], Actual: %v]", database.applied)
+	}
+
+	if testx.CompareString("down statement", "ALTER TABLE accounts DROP COLUMN email;", state.statements[4], t) {
+		return
+	}
+}
+
+func TestMigratorDownWithoutDownMigration(t *testing.T) {
+	db, state := openFakeDB(t)
+	database := newFakeMigrationDatabase(state)
+	database.applied[1] = true
+
+	migration := &Migration{Version: 1, Name: "create_accounts", Up: "CREATE TABLE accounts(id SERIAL);"}
+	_, err := NewMigrator(db).WithMigrations(migration).Down(context.Background(), 1)
+	if err == nil {
+		t.Fatalf("Param err [Expected error, Actual: nil]")
+	}
+
+	if !database.applied[1] {
+		t.Errorf("Param applied [Expected migration to stay recorded, Actual: %v]", database.applied)
+	}
+
+	if testx.CompareInt("transactions", 0, state.commits+state.rollbacks, t) {
+		return
+	}
+}
+
+func TestMigratorWithSchema(t *testing.T) {
+	db, state := openFakeDB(t)
+	newFakeMigrationDatabase(state)
+
+	_, err := NewMigrator(db).WithMigrations(testMigrations()[0]).WithSchema("tenant_a").Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		AdvisoryLockStatement,
+		`SET search_path TO "tenant_a", public;`,
+		"CREATE TABLE IF NOT EXISTS tenant_a.schema_migrations(version BIGINT PRIMARY KEY NOT NULL,name TEXT NOT NULL,applied_at BIGINT NOT NULL);",
+		MigrationsTableExistsStatement,
+		"SELECT version FROM tenant_a.schema_migrations ORDER BY version;",
+		"CREATE TABLE accounts(id SERIAL);",
+		"INSERT INTO tenant_a.schema_migrations(version,name,applied_at) VALUES($1,$2,$3);",
+		ResetSearchPathStatement,
+		AdvisoryUnlockStatement,
+	}
+	if testx.CompareString("statements", strings.Join(expected, "\n"), strings.Join(state.statements, "\n"), t) {
+		return
+	}
+}
+
+func TestInitializeSchemaBaseline(t *testing.T) {
+	creator := NewDatabaseCreator("app").
+		WithSchema("app").
+		WithMigrations(testMigrations()[1]).
+		AddTable(NewSQLTableBuilder("accounts").WithIntColumn("id").WithTextColumn("email").Build())
+
+	// fresh schema, the table is created with email and the migration is only recorded
+	db, state := openFakeDB(t)
+	database := newFakeMigrationDatabase(state)
+	err := creator.initializeSchema(context.Background(), db, "app", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !database.applied[2] || strings.Contains(strings.Join(state.statements, "\n"), "ADD COLUMN email") {
+		t.Errorf("expected migration to be recorded without running: %v", state.statements)
+		return
+	}
+
+	// existing table, the migration is applied
+	db, state = openFakeDB(t)
+	database = newFakeMigrationDatabase(state)
+	database.tables["app.accounts"] = true
+	err = creator.initializeSchema(context.Background(), db, "app", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !database.applied[2] || !strings.Contains(strings.Join(state.statements, "\n"), "ADD COLUMN email") {
+		t.Errorf("expected migration to run on existing table: %v", state.statements)
+		return
+	}
+}
@@ -0,0 +1,507 @@
+package sqlx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ellsol/gox/typex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	InspectColumnsStatement    = "SELECT column_name, data_type, udt_name, is_nullable, character_maximum_length, numeric_precision, numeric_scale FROM information_schema.columns WHERE table_schema = COALESCE(NULLIF($2, ''), current_schema()) AND table_name = $1 ORDER BY ordinal_position;"
+	InspectPrimaryKeyStatement = "SELECT tc.constraint_name, kcu.column_name FROM information_schema.table_constraints tc JOIN information_schema.key_column_usage kcu ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema AND tc.table_name = kcu.table_name WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = COALESCE(NULLIF($2, ''), current_schema()) AND tc.table_name = $1 ORDER BY kcu.ordinal_position;"
+
+	AddColumnStatement      = "ALTER TABLE %v ADD COLUMN %v;"
+	AlterTypeStatement      = "ALTER TABLE %v ALTER COLUMN %v TYPE %v USING %v::%v;"
+	SetNotNullStatement     = "ALTER TABLE %v ALTER COLUMN %v SET NOT NULL;"
+	DropNotNullStatement    = "ALTER TABLE %v ALTER COLUMN %v DROP NOT NULL;"
+	DropConstraintStatement = "ALTER TABLE %v DROP CONSTRAINT %v;"
+	AddPrimaryKeyStatement  = "ALTER TABLE %v ADD PRIMARY KEY (%v);"
+)
+
+/*
+	Table as it exists in the database
+ */
+type LiveTable struct {
+	Name                 string
+	Exists               bool
+	Columns              []*LiveColumn
+	PrimaryKeyConstraint string
+}
+
+type LiveColumn struct {
+	Name      string
+	Type      string
+	NotNull   bool
+	IsPrimary bool
+}
+
+type ColumnMismatch struct {
+	Column   string
+	Expected string
+	Actual   string
+}
+
+/*
+	Differences between a SQLTableDefinition and the live table
+ */
+type TableDiff struct {
+	Definition            *SQLTableDefinition
+	Live                  *LiveTable
+	TableMissing          bool
+	MissingColumns        []SQLTableColumn
+	ExtraColumns          []*LiveColumn
+	TypeMismatches        []*ColumnMismatch
+	NullabilityMismatches []*ColumnMismatch
+	PrimaryKeyMismatch    *ColumnMismatch
+}
+
+/*
+	Error returned by CheckSchema, holds all tables differing from their definition
+ */
+type SchemaMismatchError struct {
+	Diffs []*TableDiff
+}
+
+func (it *SchemaMismatchError) Error() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("%v tables differ from their definition:\n", len(it.Diffs)))
+	for _, v := range it.Diffs {
+		buffer.WriteString(v.String())
+	}
+	return buffer.String()
+}
+
+/*
+	Reads columns and primary key of tableName, in the current schema if not qualified, from information_schema, only available for postgres
+ */
+func (pg *SQLDB) InspectTable(ctx context.Context, tableName string) (*LiveTable, error) {
+	if pg.dialect() != Postgres {
+		return nil, fmt.Errorf("inspecting tables is not supported by %v", pg.dialect().Name())
+	}
+
+	result := &LiveTable{
+		Name:    tableName,
+		Columns: make([]*LiveColumn, 0),
+	}
+
+	schema, name := splitQualifiedName(tableName)
+	rows, err := pg.Connection.QueryContext(ctx, InspectColumnsStatement, name, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, dataType, udtName, isNullable string
+		var maxLength, precision, scale sql.NullInt64
+		err = rows.Scan(&name, &dataType, &udtName, &isNullable, &maxLength, &precision, &scale)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Columns = append(result.Columns, &LiveColumn{
+			Name:    name,
+			Type:    liveColumnType(dataType, udtName, maxLength, precision, scale),
+			NotNull: isNullable == "NO",
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	result.Exists = len(result.Columns) > 0
+	if !result.Exists {
+		return result, nil
+	}
+
+	pkRows, err := pg.Connection.QueryContext(ctx, InspectPrimaryKeyStatement, name, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer pkRows.Close()
+
+	for pkRows.Next() {
+		var constraint, column string
+		err = pkRows.Scan(&constraint, &column)
+		if err != nil {
+			return nil, err
+		}
+
+		result.PrimaryKeyConstraint = constraint
+		for _, v := range result.Columns {
+			if v.Name == column {
+				v.IsPrimary = true
+			}
+		}
+	}
+
+	return result, pkRows.Err()
+}
+
+func (pg *SQLDB) DiffTable(ctx context.Context, definition *SQLTableDefinition) (*TableDiff, error) {
+	live, err := pg.InspectTable(ctx, definition.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffTableDefinition(definition, live), nil
+}
+
+/*
+	Startup check, returns a SchemaMismatchError if any of the definitions differs from the database
+ */
+func (pg *SQLDB) CheckSchema(ctx context.Context, definitions ...*SQLTableDefinition) error {
+	diffs := make([]*TableDiff, 0)
+	for _, v := range definitions {
+		diff, err := pg.DiffTable(ctx, v)
+		if err != nil {
+			return err
+		}
+
+		if !diff.IsEmpty() {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	if len(diffs) > 0 {
+		return &SchemaMismatchError{Diffs: diffs}
+	}
+
+	return nil
+}
+
+func DiffTableDefinition(definition *SQLTableDefinition, live *LiveTable) *TableDiff {
+	diff := &TableDiff{
+		Definition:            definition,
+		Live:                  live,
+		MissingColumns:        make([]SQLTableColumn, 0),
+		ExtraColumns:          make([]*LiveColumn, 0),
+		TypeMismatches:        make([]*ColumnMismatch, 0),
+		NullabilityMismatches: make([]*ColumnMismatch, 0),
+	}
+
+	if !live.Exists {
+		diff.TableMissing = true
+		return diff
+	}
+
+	// postgres folds unquoted names to lower case
+	liveColumns := make(map[string]*LiveColumn, len(live.Columns))
+	for _, v := range live.Columns {
+		liveColumns[strings.ToLower(v.Name)] = v
+	}
+
+	expectedPrimary := typex.MapStringList(definition.PrimaryKeyColumns(), strings.ToLower)
+	for _, column := range definition.Columns {
+		name := strings.ToLower(column.Name)
+		liveColumn, ok := liveColumns[name]
+		if !ok {
+			diff.MissingColumns = append(diff.MissingColumns, column)
+			continue
+		}
+		delete(liveColumns, name)
+
+		expectedType := NormalizeColumnType(column.Type)
+		if expectedType != liveColumn.Type {
+			diff.TypeMismatches = append(diff.TypeMismatches, &ColumnMismatch{
+				Column:   column.Name,
+				Expected: expectedType,
+				Actual:   liveColumn.Type,
+			})
+		}
+
+		// primary keys are always not null
+		expectedNotNull := column.NotNULL || typex.StringListContains(name, expectedPrimary)
+		if expectedNotNull != liveColumn.NotNull {
+			diff.NullabilityMismatches = append(diff.NullabilityMismatches, &ColumnMismatch{
+				Column:   column.Name,
+				Expected: nullability(expectedNotNull),
+				Actual:   nullability(liveColumn.NotNull),
+			})
+		}
+	}
+
+	livePrimary := make([]string, 0)
+	for _, v := range live.Columns {
+		if v.IsPrimary {
+			livePrimary = append(livePrimary, strings.ToLower(v.Name))
+		}
+
+		if _, ok := liveColumns[strings.ToLower(v.Name)]; ok {
+			diff.ExtraColumns = append(diff.ExtraColumns, v)
+		}
+	}
+
+	// live columns are in table order, not in key order
+	if !sameColumns(expectedPrimary, livePrimary) {
+		diff.PrimaryKeyMismatch = &ColumnMismatch{
+			Column:   "PRIMARY KEY",
+			Expected: strings.Join(expectedPrimary, ","),
+			Actual:   strings.Join(livePrimary, ","),
+		}
+	}
+
+	return diff
+}
+
+func sameColumns(a []string, b []string) bool {
+	sortedA := append([]string{}, a...)
+	sortedB := append([]string{}, b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	return strings.Join(sortedA, ",") == strings.Join(sortedB, ",")
+}
+
+func (it *TableDiff) IsEmpty() bool {
+	return !it.TableMissing &&
+		len(it.MissingColumns) == 0 &&
+		len(it.ExtraColumns) == 0 &&
+		len(it.TypeMismatches) == 0 &&
+		len(it.NullabilityMismatches) == 0 &&
+		it.PrimaryKeyMismatch == nil
+}
+
+/*
+	Statements reconciling the live table with its definition. Extra columns are only reported, never dropped
+ */
+func (it *TableDiff) AlterStatements() []string {
+	table := it.Definition.Name()
+	result := make([]string, 0)
+
+	if it.TableMissing {
+		return append(result, it.Definition.CreateStatement())
+	}
+
+	for _, v := range it.MissingColumns {
+		column := v
+		// primary key is added separately below
+		column.IsPrimary = false
+		result = append(result, fmt.Sprintf(AddColumnStatement, table, column.Statement(false)))
+	}
+
+	for _, v := range it.TypeMismatches {
+		alterType := alterableType(it.definitionColumn(v.Column).Type)
+		result = append(result, fmt.Sprintf(AlterTypeStatement, table, v.Column, alterType, v.Column, alterType))
+	}
+
+	for _, v := range it.NullabilityMismatches {
+		if v.Expected == nullability(true) {
+			result = append(result, fmt.Sprintf(SetNotNullStatement, table, v.Column))
+		} else {
+			result = append(result, fmt.Sprintf(DropNotNullStatement, table, v.Column))
+		}
+	}
+
+	if it.PrimaryKeyMismatch != nil {
+		if it.Live.PrimaryKeyConstraint != "" {
+			result = append(result, fmt.Sprintf(DropConstraintStatement, table, it.Live.PrimaryKeyConstraint))
+		}
+		if it.PrimaryKeyMismatch.Expected != "" {
+			result = append(result, fmt.Sprintf(AddPrimaryKeyStatement, table, it.PrimaryKeyMismatch.Expected))
+		}
+	}
+
+	return result
+}
+
+/*
+	Human readable report of the differences, e.g. for command line tools
+ */
+func (it *TableDiff) String() string {
+	var buffer bytes.Buffer
+	table := it.Definition.Name()
+
+	if it.IsEmpty() {
+		buffer.WriteString(fmt.Sprintf("table %v: up to date\n", table))
+		return buffer.String()
+	}
+
+	buffer.WriteString(fmt.Sprintf("table %v:\n", table))
+	if it.TableMissing {
+		buffer.WriteString("  table does not exist\n")
+	}
+
+	for _, v := range it.MissingColumns {
+		buffer.WriteString(fmt.Sprintf("  missing column %v %v\n", v.Name, v.Type))
+	}
+
+	for _, v := range it.ExtraColumns {
+		buffer.WriteString(fmt.Sprintf("  column %v %v only exists in the database\n", v.Name, v.Type))
+	}
+
+	for _, v := range it.TypeMismatches {
+		buffer.WriteString(fmt.Sprintf("  column %v has type %v, expected %v\n", v.Column, v.Actual, v.Expected))
+	}
+
+	for _, v := range it.NullabilityMismatches {
+		buffer.WriteString(fmt.Sprintf("  column %v is %v, expected %v\n", v.Column, v.Actual, v.Expected))
+	}
+
+	if it.PrimaryKeyMismatch != nil {
+		buffer.WriteString(fmt.Sprintf("  primary key is (%v), expected (%v)\n", it.PrimaryKeyMismatch.Actual, it.PrimaryKeyMismatch.Expected))
+	}
+
+	return buffer.String()
+}
+
+func (it *TableDiff) definitionColumn(name string) SQLTableColumn {
+	for _, v := range it.Definition.Columns {
+		if v.Name == name {
+			return v
+		}
+	}
+	return SQLTableColumn{}
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "NULL"
+}
+
+var (
+	typeWithModifier = regexp.MustCompile(`^([A-Z ]+?)\s*\(([0-9 ,]+)\)$`)
+
+	// declared types mapped to their information_schema data_type
+	normalizedTypes = map[string]string{
+		"SERIAL":                      "integer",
+		"SERIAL4":                     "integer",
+		"INT":                         "integer",
+		"INT4":                        "integer",
+		"INTEGER":                     "integer",
+		"BIGSERIAL":                   "bigint",
+		"SERIAL8":                     "bigint",
+		"BIGINT":                      "bigint",
+		"INT8":                        "bigint",
+		"SMALLINT":                    "smallint",
+		"INT2":                        "smallint",
+		"TEXT":                        "text",
+		"BOOLEAN":                     "boolean",
+		"BOOL":                        "boolean",
+		"BYTEA":                       "bytea",
+		"REAL":                        "real",
+		"FLOAT4":                      "real",
+		"DOUBLE PRECISION":            "double precision",
+		"FLOAT8":                      "double precision",
+		"NUMERIC":                     "numeric",
+		"DECIMAL":                     "numeric",
+		"VARCHAR":                     "character varying",
+		"CHARACTER VARYING":           "character varying",
+		"CHAR":                        "character",
+		"CHARACTER":                   "character",
+		"BPCHAR":                      "character",
+		"DATE":                        "date",
+		"TIMESTAMP":                   "timestamp without time zone",
+		"TIMESTAMPTZ":                 "timestamp with time zone",
+		"TIMESTAMP WITH TIME ZONE":    "timestamp with time zone",
+		"TIMESTAMP WITHOUT TIME ZONE": "timestamp without time zone",
+		"UUID":                        "uuid",
+		"JSON":                        "json",
+		"JSONB":                       "jsonb",
+	}
+
+	// information_schema data_type mapped to the udt_name postgres uses for its arrays
+	arrayElementTypes = map[string]string{
+		"integer":                     "int4",
+		"bigint":                      "int8",
+		"smallint":                    "int2",
+		"boolean":                     "bool",
+		"real":                        "float4",
+		"double precision":            "float8",
+		"character varying":           "varchar",
+		"character":                   "bpchar",
+		"timestamp with time zone":    "timestamptz",
+		"timestamp without time zone": "timestamp",
+	}
+)
+
+/*
+	Maps a declared column type to the form reported by InspectTable, e.g. SERIAL -> integer, VARCHAR(20) -> character varying(20)
+ */
+func NormalizeColumnType(columnType string) string {
+	upper := strings.ToUpper(strings.TrimSpace(columnType))
+
+	if strings.HasSuffix(upper, "[]") {
+		// the udt_name of arrays has no modifiers, e.g. _numeric for NUMERIC(10,2)[]
+		element := NormalizeColumnType(strings.TrimSuffix(upper, "[]"))
+		if pos := strings.Index(element, "("); pos >= 0 {
+			element = element[:pos]
+		}
+
+		if udt, ok := arrayElementTypes[element]; ok {
+			return "_" + udt
+		}
+		return "_" + element
+	}
+
+	if match := typeWithModifier.FindStringSubmatch(upper); match != nil {
+		modifier := strings.Replace(match[2], " ", "", -1)
+		return fmt.Sprintf("%v(%v)", normalizedTypeName(match[1]), modifier)
+	}
+
+	if normalized, ok := normalizedTypes[upper]; ok {
+		// CHAR without length is CHAR(1)
+		if normalized == "character" {
+			return "character(1)"
+		}
+		return normalized
+	}
+
+	// e.g. enum types
+	return strings.ToLower(strings.TrimSpace(columnType))
+}
+
+// like NormalizeColumnType for the name of a type with modifier, e.g. CHAR of CHAR(3)
+func normalizedTypeName(name string) string {
+	if normalized, ok := normalizedTypes[strings.TrimSpace(name)]; ok {
+		return normalized
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// schema is empty for unqualified names
+func splitQualifiedName(name string) (string, string) {
+	pos := strings.LastIndex(name, ".")
+	if pos < 0 {
+		return "", name
+	}
+	return name[:pos], name[pos+1:]
+}
+
+func liveColumnType(dataType string, udtName string, maxLength sql.NullInt64, precision sql.NullInt64, scale sql.NullInt64) string {
+	switch dataType {
+	case "ARRAY", "USER-DEFINED":
+		return udtName
+	case "character varying", "character":
+		if maxLength.Valid {
+			return fmt.Sprintf("%v(%v)", dataType, maxLength.Int64)
+		}
+	case "numeric":
+		if precision.Valid && scale.Valid {
+			return fmt.Sprintf("numeric(%v,%v)", precision.Int64, scale.Int64)
+		}
+	}
+
+	return dataType
+}
+
+/*
+	Serial types are no real types and can not be used in ALTER COLUMN ... TYPE
+ */
+func alterableType(columnType string) string {
+	switch strings.ToUpper(columnType) {
+	case "SERIAL", "SERIAL4":
+		return "INTEGER"
+	case "BIGSERIAL", "SERIAL8":
+		return "BIGINT"
+	}
+	return columnType
+}
@@ -0,0 +1,139 @@
+package sqlx
+
+import (
+	"github.com/ellsol/gox/testx"
+	"testing"
+)
+
+func TestDiffTableDefinition(t *testing.T) {
+	definition := NewSQLTableBuilder("accounts").
+		WithSerialColumn("id", NotNull, IsPrimary).
+		WithTextColumn("name", NotNull).
+		WithBigIntColumn("balance").
+		WithColumnDefinition("code", "VARCHAR(12)").
+		Build()
+
+	live := &LiveTable{
+		Name:                 "accounts",
+		Exists:               true,
+		PrimaryKeyConstraint: "accounts_pkey",
+		Columns: []*LiveColumn{
+			{Name: "id", Type: "integer", NotNull: true, IsPrimary: true},
+			{Name: "name", Type: "text"},
+			{Name: "code", Type: "character varying(10)"},
+			{Name: "legacy", Type: "text"},
+		},
+	}
+
+	diff := DiffTableDefinition(definition, live)
+	if diff.IsEmpty() {
+		t.Errorf("expected differences")
+		return
+	}
+
+	if testx.CompareInt("missing", 1, len(diff.MissingColumns), t) ||
+		testx.CompareInt("extra", 1, len(diff.ExtraColumns), t) ||
+		testx.CompareInt("types", 1, len(diff.TypeMismatches), t) ||
+		testx.CompareInt("nullability", 1, len(diff.NullabilityMismatches), t) {
+		return
+	}
+
+	if diff.PrimaryKeyMismatch != nil {
+		t.Errorf("unexpected primary key mismatch: %v", diff.PrimaryKeyMismatch)
+		return
+	}
+
+	expected := []string{
+		"ALTER TABLE accounts ADD COLUMN balance BIGINT;",
+		"ALTER TABLE accounts ALTER COLUMN code TYPE VARCHAR(12) USING code::VARCHAR(12);",
+		"ALTER TABLE accounts ALTER COLUMN name SET NOT NULL;",
+	}
+
+	statements := diff.AlterStatements()
+	if testx.CompareInt("statements", len(expected), len(statements), t) {
+		return
+	}
+
+	for k, v := range expected {
+		if testx.CompareString("statement", v, statements[k], t) {
+			return
+		}
+	}
+}
+
+func TestNormalizeColumnType(t *testing.T) {
+	cases := map[string]string{
+		"SERIAL":          "integer",
+		"BIGINT":          "bigint",
+		"varchar(20)":     "character varying(20)",
+		"NUMERIC(10, 2)":  "numeric(10,2)",
+		"TEXT[]":          "_text",
+		"INT[]":           "_int4",
+		"mood":            "mood",
+		"CHAR(3)":         "character(3)",
+		"char":            "character(1)",
+		"CHARACTER(2)":    "character(2)",
+		"NUMERIC(10,2)[]": "_numeric",
+		"VARCHAR(20)[]":   "_varchar",
+		"CHAR(2)[]":       "_bpchar",
+	}
+
+	for k, v := range cases {
+		if testx.CompareString(k, v, NormalizeColumnType(k), t) {
+			return
+		}
+	}
+}
+
+func TestDiffTableDefinitionFoldsColumnNames(t *testing.T) {
+	definition := NewSQLTableBuilder("users").
+		WithIntColumn("userId").
+		WithColumnDefinition("countryCode", "CHAR(2)").
+		WithPrimaryKey("userId").
+		Build()
+
+	live := &LiveTable{
+		Name:                 "users",
+		Exists:               true,
+		PrimaryKeyConstraint: "users_pkey",
+		Columns: []*LiveColumn{
+			{Name: "userid", Type: "integer", NotNull: true, IsPrimary: true},
+			{Name: "countrycode", Type: "character(2)"},
+		},
+	}
+
+	diff := DiffTableDefinition(definition, live)
+	if !diff.IsEmpty() {
+		t.Errorf("expected empty diff, got %v", diff.AlterStatements())
+	}
+}
+
+func TestDiffTableDefinitionCompositePrimaryKey(t *testing.T) {
+	definition := NewSQLTableBuilder("oi").
+		WithIntColumn("a").
+		WithIntColumn("b").
+		WithTextColumn("note").
+		WithPrimaryKey("b", "a").
+		Build()
+
+	live := &LiveTable{
+		Name:                 "oi",
+		Exists:               true,
+		PrimaryKeyConstraint: "oi_pkey",
+		Columns: []*LiveColumn{
+			{Name: "a", Type: "integer", NotNull: true, IsPrimary: true},
+			{Name: "b", Type: "integer", NotNull: true, IsPrimary: true},
+			{Name: "note", Type: "text"},
+		},
+	}
+
+	diff := DiffTableDefinition(definition, live)
+	if !diff.IsEmpty() {
+		t.Errorf("expected no differences, got %v", diff)
+		return
+	}
+
+	if testx.CompareInt("statements", 0, len(diff.AlterStatements()), t) {
+		return
+	}
+}
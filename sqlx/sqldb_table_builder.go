@@ -2,44 +2,220 @@ package sqlx
 
 import (
 	"bytes"
+	"fmt"
+	"github.com/ellsol/gox/typex"
+	"strings"
 )
 
 var IsPrimary = true
 var NotNull = true
 
+const (
+	OnDeleteCascade    = "CASCADE"
+	OnDeleteSetNull    = "SET NULL"
+	OnDeleteRestrict   = "RESTRICT"
+	OnDeleteNoAction   = "NO ACTION"
+	OnDeleteSetDefault = "SET DEFAULT"
+
+	CreateIndexStatement       = "CREATE INDEX IF NOT EXISTS %v ON %v (%v);"
+	CreateUniqueIndexStatement = "CREATE UNIQUE INDEX IF NOT EXISTS %v ON %v (%v);"
+)
+
 type SQLTableColumn struct {
 	Name      string
 	Type      string
 	IsPrimary bool
 	NotNULL   bool
+	Unique    bool
+	// sql expression, e.g. 0 or now()
+	Default string
+	// sql expression, e.g. balance >= 0
+	Check      string
+	References *ForeignKey
+}
+
+type ForeignKey struct {
+	Table  string
+	Column string
+	// one of the OnDelete constants, database default if empty
+	OnDelete string
+}
+
+type SQLTableIndex struct {
+	Name    string
+	Columns []string
+	Unique  bool
 }
 
 type SQLTableDefinition struct {
 	TableName string
-	Columns   []SQLTableColumn
+	// tables without schema are created in the search_path, see InSchema
+	Schema  string
+	Columns []SQLTableColumn
+	// composite primary key, use IsPrimary of the column for single column keys
+	PrimaryKey []string
+	Indexes    []*SQLTableIndex
+	// enum types used by the columns
+	Enums []*SQLEnum
 }
 
-func (definition *SQLTableDefinition) CreateStatement() string {
-	var buffer bytes.Buffer
+/*
+	Table name, qualified with the schema if set
+ */
+func (definition *SQLTableDefinition) Name() string {
+	return QualifiedName(definition.Schema, definition.TableName)
+}
 
-	buffer.WriteString("CREATE TABLE ")
-	buffer.WriteString(definition.TableName)
-	buffer.WriteString("(")
+/*
+	Copy of the table inside schema. Unqualified foreign keys and enum types are moved to schema as well
+ */
+func (definition *SQLTableDefinition) InSchema(schema string) SQLTable {
+	result := *definition
+	result.Schema = schema
+	result.Columns = make([]SQLTableColumn, len(definition.Columns))
+	result.Enums = make([]*SQLEnum, len(definition.Enums))
+
+	enumTypes := make(map[string]string)
+	for k, v := range definition.Enums {
+		result.Enums[k] = &SQLEnum{
+			TypeName: QualifiedName(schema, v.TypeName),
+			Values:   v.Values,
+		}
+		enumTypes[v.TypeName] = result.Enums[k].TypeName
+	}
 
 	for k, v := range definition.Columns {
-		lastElement := k == len(definition.Columns)-1
-		buffer.WriteString(v.Statement(!lastElement))
+		if enumType, ok := enumTypes[v.Type]; ok {
+			v.Type = enumType
+		}
+
+		if v.References != nil {
+			v.References = &ForeignKey{
+				Table:    QualifiedName(schema, v.References.Table),
+				Column:   v.References.Column,
+				OnDelete: v.References.OnDelete,
+			}
+		}
+
+		result.Columns[k] = v
+	}
+
+	return &result
+}
+
+func (definition *SQLTableDefinition) ColumnNames() []string {
+	result := make([]string, len(definition.Columns))
+
+	for k, v := range definition.Columns {
+		result[k] = v.Name
+	}
+
+	return result
+}
+
+/*
+	Columns of the composite primary key or of the column marked as primary
+ */
+func (definition *SQLTableDefinition) PrimaryKeyColumns() []string {
+	if len(definition.PrimaryKey) > 0 {
+		return definition.PrimaryKey
+	}
+
+	result := make([]string, 0)
+	for _, v := range definition.Columns {
+		if v.IsPrimary {
+			result = append(result, v.Name)
+		}
 	}
 
+	return result
+}
+
+func (definition *SQLTableDefinition) CreateStatement() string {
+	return definition.CreateStatementFor(Postgres)
+}
+
+func (definition *SQLTableDefinition) CreateStatementFor(dialect Dialect) string {
+	enums := make(map[string]*SQLEnum, len(definition.Enums))
+	for _, v := range definition.Enums {
+		enums[v.TypeName] = v
+	}
+
+	parts := make([]string, 0, len(definition.Columns)+len(definition.Indexes)+1)
+	for _, v := range definition.Columns {
+		if enum, ok := enums[v.Type]; ok {
+			v.Type = dialect.EnumColumnType(enum)
+		}
+		parts = append(parts, v.StatementFor(dialect, false))
+	}
+
+	if len(definition.PrimaryKey) > 0 {
+		parts = append(parts, fmt.Sprintf("PRIMARY KEY (%v)", typex.CommaSeparatedString(definition.PrimaryKey)))
+	}
+
+	if dialect.InlineIndexes() {
+		for _, v := range definition.Indexes {
+			index := fmt.Sprintf("INDEX %v (%v)", v.Name, typex.CommaSeparatedString(v.Columns))
+			if v.Unique {
+				index = "UNIQUE " + index
+			}
+			parts = append(parts, index)
+		}
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(dialect.CreateTablePrefix())
+	buffer.WriteString(definition.Name())
+	buffer.WriteString("(")
+	buffer.WriteString(strings.Join(parts, ","))
 	buffer.WriteString(");")
 	return buffer.String()
 }
 
+/*
+	CREATE INDEX statements of all indexes, to be executed after the table has been created
+ */
+func (definition *SQLTableDefinition) IndexStatements() []string {
+	result := make([]string, len(definition.Indexes))
+
+	for k, v := range definition.Indexes {
+		format := CreateIndexStatement
+		if v.Unique {
+			format = CreateUniqueIndexStatement
+		}
+		result[k] = fmt.Sprintf(format, v.Name, definition.Name(), typex.CommaSeparatedString(v.Columns))
+	}
+
+	return result
+}
+
+/*
+	CREATE TYPE statements of the enums used by the table, to be executed before the table is created
+ */
+func (definition *SQLTableDefinition) TypeStatements() []string {
+	return enumStatements(definition.Enums)
+}
+
+/*
+	Tables referenced by foreign keys, which have to be created first
+ */
+func (definition *SQLTableDefinition) Dependencies() []string {
+	result := make([]string, 0)
+
+	for _, v := range definition.Columns {
+		if v.References != nil && v.References.Table != definition.TableName && v.References.Table != definition.Name() && !typex.StringListContains(v.References.Table, result) {
+			result = append(result, v.References.Table)
+		}
+	}
+
+	return result
+}
+
 func (definition *SQLTableDefinition) Tags() []string {
 	tags := make([]string, 0)
 
 	for _, v := range definition.Columns {
-		if v.Type != "SERIAL" {
+		if v.Type != "SERIAL" && v.Type != "BIGSERIAL" {
 			tags = append(tags, v.Name)
 		}
 	}
@@ -91,6 +267,80 @@ func (builder *SQLTableBuilder) WithColumnDefinition(name string, valueType stri
 	return builder.WithColumn(col)
 }
 
+/*
+	Marks the last column as UNIQUE
+ */
+func (builder *SQLTableBuilder) Unique() *SQLTableBuilder {
+	return builder.withLastColumn(func(column *SQLTableColumn) {
+		column.Unique = true
+	})
+}
+
+/*
+	Sets the DEFAULT expression of the last column
+ */
+func (builder *SQLTableBuilder) WithDefault(expression string) *SQLTableBuilder {
+	return builder.withLastColumn(func(column *SQLTableColumn) {
+		column.Default = expression
+	})
+}
+
+/*
+	Sets the CHECK expression of the last column
+ */
+func (builder *SQLTableBuilder) WithCheck(expression string) *SQLTableBuilder {
+	return builder.withLastColumn(func(column *SQLTableColumn) {
+		column.Check = expression
+	})
+}
+
+/*
+	Makes the last column reference table(column), onDelete is one of the OnDelete constants or empty
+ */
+func (builder *SQLTableBuilder) References(table string, column string, onDelete string) *SQLTableBuilder {
+	return builder.withLastColumn(func(c *SQLTableColumn) {
+		c.References = &ForeignKey{
+			Table:    table,
+			Column:   column,
+			OnDelete: onDelete,
+		}
+	})
+}
+
+/*
+	Composite primary key over columns
+ */
+func (builder *SQLTableBuilder) WithPrimaryKey(columns ...string) *SQLTableBuilder {
+	builder.Definition.PrimaryKey = columns
+	return builder
+}
+
+func (builder *SQLTableBuilder) WithIndex(name string, columns ...string) *SQLTableBuilder {
+	builder.Definition.Indexes = append(builder.Definition.Indexes, &SQLTableIndex{
+		Name:    name,
+		Columns: columns,
+	})
+	return builder
+}
+
+func (builder *SQLTableBuilder) WithUniqueIndex(name string, columns ...string) *SQLTableBuilder {
+	builder.Definition.Indexes = append(builder.Definition.Indexes, &SQLTableIndex{
+		Name:    name,
+		Columns: columns,
+		Unique:  true,
+	})
+	return builder
+}
+
+// ignored if no column has been added yet
+func (builder *SQLTableBuilder) withLastColumn(fn func(column *SQLTableColumn)) *SQLTableBuilder {
+	lastElementPos := len(builder.Definition.Columns) - 1
+	if lastElementPos >= 0 {
+		fn(&builder.Definition.Columns[lastElementPos])
+	}
+	return builder
+}
+
 func (builder *SQLTableBuilder) WithSerialColumn(name string, params ...bool) *SQLTableBuilder {
 	return builder.WithColumnDefinition(name, "SERIAL", params...)
 }
@@ -115,12 +365,63 @@ func (builder *SQLTableBuilder) WithByteAColumn(name string, params ...bool) *SQ
 	return builder.WithColumnDefinition(name, "BYTEA", params...)
 }
 
+func (builder *SQLTableBuilder) WithBigSerialColumn(name string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, "BIGSERIAL", params...)
+}
+
+func (builder *SQLTableBuilder) WithTimestampTzColumn(name string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, "TIMESTAMPTZ", params...)
+}
+
+func (builder *SQLTableBuilder) WithDateColumn(name string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, "DATE", params...)
+}
+
+func (builder *SQLTableBuilder) WithNumericColumn(name string, precision int, scale int, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, NumericType(precision, scale), params...)
+}
+
+func (builder *SQLTableBuilder) WithUUIDColumn(name string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, "UUID", params...)
+}
+
+func (builder *SQLTableBuilder) WithJSONBColumn(name string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, "JSONB", params...)
+}
+
+func (builder *SQLTableBuilder) WithVarCharColumn(name string, length int, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, VarCharType(length), params...)
+}
+
+func (builder *SQLTableBuilder) WithDoublePrecisionColumn(name string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, "DOUBLE PRECISION", params...)
+}
+
+/*
+	Array column of elementType, e.g. TEXT for TEXT[]
+ */
+func (builder *SQLTableBuilder) WithArrayColumn(name string, elementType string, params ...bool) *SQLTableBuilder {
+	return builder.WithColumnDefinition(name, ArrayType(elementType), params...)
+}
+
+/*
+	Column of an enum type, the type is created together with the table
+ */
+func (builder *SQLTableBuilder) WithEnumColumn(name string, enum *SQLEnum, params ...bool) *SQLTableBuilder {
+	builder.Definition.Enums = appendEnum(builder.Definition.Enums, enum)
+	return builder.WithColumnDefinition(name, enum.TypeName, params...)
+}
+
 func (column *SQLTableColumn) Statement(withComma bool) string {
+	return column.StatementFor(Postgres, withComma)
+}
+
+func (column *SQLTableColumn) StatementFor(dialect Dialect, withComma bool) string {
 	var buffer bytes.Buffer
 
 	buffer.WriteString(column.Name)
 	buffer.WriteString(" ")
-	buffer.WriteString(column.Type)
+	buffer.WriteString(dialect.ColumnType(column.Type))
 
 	if column.IsPrimary {
 		buffer.WriteString(" PRIMARY KEY")
@@ -130,9 +431,41 @@ func (column *SQLTableColumn) Statement(withComma bool) string {
 		buffer.WriteString(" NOT NULL")
 	}
 
+	buffer.WriteString(columnConstraints(column.Unique, column.Default, column.Check, column.References))
+
 	if withComma {
 		buffer.WriteString(",")
 	}
 
 	return buffer.String()
 }
+
+func columnConstraints(unique bool, defaultExpression string, check string, references *ForeignKey) string {
+	parts := make([]string, 0)
+
+	if unique {
+		parts = append(parts, "UNIQUE")
+	}
+
+	if defaultExpression != "" {
+		parts = append(parts, "DEFAULT "+defaultExpression)
+	}
+
+	if check != "" {
+		parts = append(parts, fmt.Sprintf("CHECK (%v)", check))
+	}
+
+	if references != nil {
+		reference := fmt.Sprintf("REFERENCES %v(%v)", references.Table, references.Column)
+		if references.OnDelete != "" {
+			reference += " ON DELETE " + references.OnDelete
+		}
+		parts = append(parts, reference)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " " + strings.Join(parts, " ")
+}
@@ -0,0 +1,198 @@
+package sqlx
+
+import (
+	"testing"
+	"github.com/ellsol/gox/testx"
+	"github.com/ellsol/gox/typex"
+)
+
+func TestSQLTableBuilderConstraints(t *testing.T) {
+	definition := NewSQLTableBuilder("order_items").
+		WithBigIntColumn("order_id", NotNull).References("orders", "id", OnDeleteCascade).
+		WithIntColumn("position", NotNull).WithCheck("position >= 0").
+		WithTextColumn("sku", NotNull).Unique().
+		WithIntColumn("quantity", NotNull).WithDefault("1").
+		WithPrimaryKey("order_id", "position").
+		WithIndex("order_items_sku_idx", "sku").
+		WithUniqueIndex("order_items_order_sku_idx", "order_id", "sku").
+		Build()
+
+	expected := "CREATE TABLE order_items(order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,position INT NOT NULL CHECK (position >= 0),sku TEXT NOT NULL UNIQUE,quantity INT NOT NULL DEFAULT 1,PRIMARY KEY (order_id,position));"
+	if testx.CompareString("create statement", expected, definition.CreateStatement(), t) {
+		return
+	}
+
+	indexes := definition.IndexStatements()
+	if testx.CompareInt("indexes", 2, len(indexes), t) ||
+		testx.CompareString("index", "CREATE INDEX IF NOT EXISTS order_items_sku_idx ON order_items (sku);", indexes[0], t) ||
+		testx.CompareString("unique index", "CREATE UNIQUE INDEX IF NOT EXISTS order_items_order_sku_idx ON order_items (order_id,sku);", indexes[1], t) {
+		return
+	}
+}
+
+type testDependentTable struct {
+	testTable
+	dependencies []string
+}
+
+func (it *testDependentTable) Dependencies() []string {
+	return it.dependencies
+}
+
+func TestSortTablesByDependencies(t *testing.T) {
+	tables := map[string]SQLTable{
+		"a_items":  &testDependentTable{testTable{name: "a_items"}, []string{"orders", "products"}},
+		"orders":   &testDependentTable{testTable{name: "orders"}, []string{"users", "unknown"}},
+		"products": &testTable{name: "products"},
+		"users":    &testTable{name: "users"},
+	}
+
+	sorted, err := SortTablesByDependencies(tables)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	names := make([]string, len(sorted))
+	for k, v := range sorted {
+		names[k] = v.Name()
+	}
+
+	if testx.CompareString("order", "users,orders,products,a_items", typex.CommaSeparatedString(names), t) {
+		return
+	}
+
+	tables["users"] = &testDependentTable{testTable{name: "users"}, []string{"a_items"}}
+	_, err = SortTablesByDependencies(tables)
+	if err == nil {
+		t.Errorf("expected error for cyclic dependency")
+	}
+}
+
+func TestSQLTableBuilderColumnTypes(t *testing.T) {
+	status := NewSQLEnum("order_status", "open", "it's done")
+	definition := NewSQLTableBuilder("orders").
+		WithBigSerialColumn("id", NotNull, IsPrimary).
+		WithUUIDColumn("reference", NotNull).
+		WithVarCharColumn("code", 12).
+		WithNumericColumn("amount", 10, 2, NotNull).
+		WithDoublePrecisionColumn("rate").
+		WithTimestampTzColumn("created", NotNull).WithDefault("now()").
+		WithDateColumn("due").
+		WithJSONBColumn("meta").
+		WithArrayColumn("tags", "TEXT").
+		WithEnumColumn("status", status, NotNull).
+		Build()
+
+	expected := "CREATE TABLE orders(id BIGSERIAL PRIMARY KEY NOT NULL,reference UUID NOT NULL,code VARCHAR(12),amount NUMERIC(10,2) NOT NULL,rate DOUBLE PRECISION,created TIMESTAMPTZ NOT NULL DEFAULT now(),due DATE,meta JSONB,tags TEXT[],status order_status NOT NULL);"
+	if testx.CompareString("create statement", expected, definition.CreateStatement(), t) {
+		return
+	}
+
+	types := definition.TypeStatements()
+	if testx.CompareInt("types", 1, len(types), t) ||
+		testx.CompareString("enum", "CREATE TYPE order_status AS ENUM ('open', 'it''s done');", types[0], t) {
+		return
+	}
+
+	if testx.CompareInt("tags", 9, len(definition.Tags()), t) {
+		return
+	}
+}
+
+func TestSQLTableDefinitionPrimaryKey(t *testing.T) {
+	var table SQLTable = NewSQLTableBuilder("accounts").
+		WithTextColumn("name", NotNull).
+		WithSerialColumn("id", NotNull, IsPrimary).
+		WithBigIntColumn("balance").
+		Build()
+
+	if testx.CompareString("insert", "INSERT INTO accounts(name,balance) VALUES($1,$2);", GetPostgresInsertStatementNoIncrementOmitPrimary(table), t) ||
+		testx.CompareString("update", "UPDATE accounts SET name = $2,balance = $3 WHERE id = $1;", CreateUpdateStatement(table, "id"), t) {
+		return
+	}
+
+	legacy := &testTable{name: "accounts", columns: []string{"id", "name", "balance"}}
+	if testx.CompareString("legacy insert", "INSERT INTO accounts(name,balance) VALUES($1,$2);", GetPostgresInsertStatementNoIncrementOmitPrimary(legacy), t) ||
+		testx.CompareString("legacy update", "UPDATE accounts SET name = $2,balance = $3 WHERE id = $1;", CreateUpdateStatement(legacy, "id"), t) {
+		return
+	}
+
+	// every value passed is bound, the key first
+	users := &testTable{name: "users", columns: []string{"id", "name", "email"}}
+	if testx.CompareString("non primary key", "UPDATE users SET name = $2,email = $3 WHERE email = $1;", CreateUpdateStatement(users, "email"), t) {
+		return
+	}
+
+	// composite keys match all key columns, the key values bound first
+	positions := NewSQLTableBuilder("positions").
+		WithBigIntColumn("order_id", NotNull).
+		WithBigIntColumn("position", NotNull).
+		WithTextColumn("article").
+		WithPrimaryKey("order_id", "position").
+		Build()
+	if testx.CompareString("composite key", "UPDATE positions SET article = $3 WHERE order_id = $1 AND position = $2;", CreateUpdateStatement(positions, "order_id"), t) {
+		return
+	}
+
+	columns := NewColumnDefinition("accounts").
+		WithTextColumn("name", true).
+		WithSerialColumn("id").AsPrimary()
+	if testx.CompareString("column definition", "name", typex.CommaSeparatedString(ColumnNamesOmitPrimary(columns)), t) ||
+		testx.CompareString("column definition create", "CREATE TABLE accounts(name TEXT NOT NULL,id SERIAL PRIMARY KEY);", columns.CreateStatement(), t) {
+		return
+	}
+}
+
+func TestSQLTableDefinitionInSchema(t *testing.T) {
+	users := NewSQLTableBuilder("users").
+		WithSerialColumn("id", NotNull, IsPrimary).
+		Build()
+
+	orders := NewSQLTableBuilder("orders").
+		WithSerialColumn("id", NotNull, IsPrimary).
+		WithIntColumn("user_id", NotNull).References("users", "id", OnDeleteCascade).
+		WithEnumColumn("status", NewSQLEnum("order_status", "open", "closed"), NotNull).
+		WithIndex("orders_user_idx", "user_id").
+		Build()
+
+	tables := TablesInSchema("tenant_a", map[string]SQLTable{
+		users.Name():  users,
+		orders.Name(): orders,
+		"legacy":      &testTable{name: "legacy"},
+	})
+
+	qualified, ok := tables["tenant_a.orders"].(*SQLTableDefinition)
+	if !ok {
+		t.Errorf("expected tenant_a.orders in %v", tables)
+		return
+	}
+
+	expected := "CREATE TABLE tenant_a.orders(id SERIAL PRIMARY KEY NOT NULL,user_id INT NOT NULL REFERENCES tenant_a.users(id) ON DELETE CASCADE,status tenant_a.order_status NOT NULL);"
+	if testx.CompareString("create statement", expected, qualified.CreateStatement(), t) ||
+		testx.CompareString("type", "CREATE TYPE tenant_a.order_status AS ENUM ('open', 'closed');", qualified.TypeStatements()[0], t) ||
+		testx.CompareString("index", "CREATE INDEX IF NOT EXISTS orders_user_idx ON tenant_a.orders (user_id);", qualified.IndexStatements()[0], t) ||
+		testx.CompareString("original", "orders", orders.Name(), t) {
+		return
+	}
+
+	if _, ok := tables["legacy"]; !ok {
+		t.Errorf("expected tables without schema support to be kept")
+		return
+	}
+
+	sorted, err := SortTablesByDependencies(tables)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	names := make([]string, len(sorted))
+	for k, v := range sorted {
+		names[k] = v.Name()
+	}
+
+	if testx.CompareString("order", "legacy,tenant_a.users,tenant_a.orders", typex.CommaSeparatedString(names), t) {
+		return
+	}
+}
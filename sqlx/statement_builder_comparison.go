@@ -0,0 +1,192 @@
+package sqlx
+
+import (
+	"fmt"
+	"github.com/lib/pq"
+	"strings"
+)
+
+func (it *StatementBuilder) AddNotEqualCondition(conditionLabel string, conditionValue interface{}) *StatementBuilder {
+	return it.addCondition(NotEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddLessThanCondition(conditionLabel string, conditionValue interface{}) *StatementBuilder {
+	return it.addCondition(LessThanCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddLessOrEqualCondition(conditionLabel string, conditionValue interface{}) *StatementBuilder {
+	return it.addCondition(LessOrEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddGreaterThanCondition(conditionLabel string, conditionValue interface{}) *StatementBuilder {
+	return it.addCondition(GreaterThanCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddGreaterOrEqualCondition(conditionLabel string, conditionValue interface{}) *StatementBuilder {
+	return it.addCondition(GreaterOrEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddIsNullCondition(conditionLabel string) *StatementBuilder {
+	return it.addCondition(IsNullCondition(conditionLabel))
+}
+
+func (it *StatementBuilder) AddIsNotNullCondition(conditionLabel string) *StatementBuilder {
+	return it.addCondition(IsNotNullCondition(conditionLabel))
+}
+
+func (it *StatementBuilder) AddNotInCondition(conditionLabel string, values []string) *StatementBuilder {
+	if len(values) == 0 {
+		return it
+	}
+
+	params := make([]interface{}, len(values))
+	for k, v := range values {
+		params[k] = v
+	}
+
+	return it.addCondition(NotInCondition(conditionLabel, params))
+}
+
+func (it *StatementBuilder) AddILikeCondition(conditionLabel string, conditionValue string) *StatementBuilder {
+	return it.addCondition(ILikeCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddILikePrefixCondition(conditionLabel string, conditionValue string) *StatementBuilder {
+	return it.addCondition(ILikePrefixCondition(conditionLabel, conditionValue))
+}
+
+func (it *StatementBuilder) AddArrayContainsCondition(conditionLabel string, values interface{}) *StatementBuilder {
+	return it.addCondition(ArrayContainsCondition(conditionLabel, values))
+}
+
+func (it *StatementBuilder) AddArrayOverlapCondition(conditionLabel string, values interface{}) *StatementBuilder {
+	return it.addCondition(ArrayOverlapCondition(conditionLabel, values))
+}
+
+func NotEqualCondition(conditionLabel string, conditionValue interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "<>",
+		value:    conditionValue,
+	}
+}
+
+func LessThanCondition(conditionLabel string, conditionValue interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "<",
+		value:    conditionValue,
+	}
+}
+
+func LessOrEqualCondition(conditionLabel string, conditionValue interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "<=",
+		value:    conditionValue,
+	}
+}
+
+func GreaterThanCondition(conditionLabel string, conditionValue interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: ">",
+		value:    conditionValue,
+	}
+}
+
+func GreaterOrEqualCondition(conditionLabel string, conditionValue interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: ">=",
+		value:    conditionValue,
+	}
+}
+
+func IsNullCondition(conditionLabel string) Condition {
+	return &nullCondition{
+		label: conditionLabel,
+	}
+}
+
+func IsNotNullCondition(conditionLabel string) Condition {
+	return &nullCondition{
+		label:   conditionLabel,
+		notNull: true,
+	}
+}
+
+/*
+	Always matches if values is empty
+ */
+func NotInCondition(conditionLabel string, values []interface{}) Condition {
+	return &inCondition{
+		label:  conditionLabel,
+		values: values,
+		not:    true,
+	}
+}
+
+/*
+	Case insensitive match of rows whose conditionLabel contains conditionValue, wildcards in conditionValue are escaped
+ */
+func ILikeCondition(conditionLabel string, conditionValue string) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "ILIKE",
+		value:    "%" + EscapeLikePattern(conditionValue) + "%",
+	}
+}
+
+/*
+	Case insensitive match of rows whose conditionLabel starts with conditionValue, wildcards in conditionValue are escaped
+ */
+func ILikePrefixCondition(conditionLabel string, conditionValue string) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "ILIKE",
+		value:    EscapeLikePattern(conditionValue) + "%",
+	}
+}
+
+/*
+	conditionLabel @> values, values has to be a slice
+ */
+func ArrayContainsCondition(conditionLabel string, values interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "@>",
+		value:    pq.Array(values),
+	}
+}
+
+/*
+	conditionLabel && values, values has to be a slice
+ */
+func ArrayOverlapCondition(conditionLabel string, values interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "&&",
+		value:    pq.Array(values),
+	}
+}
+
+/*
+	Escapes the LIKE wildcards % and _ and the escape character itself
+ */
+func EscapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+type nullCondition struct {
+	label   string
+	notNull bool
+}
+
+func (it *nullCondition) render(ctx *renderContext) string {
+	if it.notNull {
+		return fmt.Sprintf("%v IS NOT NULL", ctx.identifier(it.label))
+	}
+	return fmt.Sprintf("%v IS NULL", ctx.identifier(it.label))
+}
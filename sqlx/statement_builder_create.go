@@ -1,13 +1,13 @@
 package sqlx
 
-import (
-	"bytes"
-)
-
-
+/*
+	Builder for SQLTableDefinition taking an explicit notNull flag, implements SQLTable by delegating
+	to the built definition. Prefer SQLTableBuilder for new tables
+ */
 type ColumnDefinition struct {
 	TableName string
 	Columns   []TableColumn
+	Enums     []*SQLEnum
 }
 
 func NewColumnDefinition(tableName string) *ColumnDefinition {
@@ -21,33 +21,63 @@ func NewColumnDefinition(tableName string) *ColumnDefinition {
 	Encodes column definition as SQL string
  */
 func (it *ColumnDefinition) SqlString() string {
-	var buffer bytes.Buffer
+	return it.SqlStringFor(Postgres)
+}
 
-	buffer.WriteString("CREATE TABLE ")
-	buffer.WriteString(it.TableName)
-	buffer.WriteString("(")
+func (it *ColumnDefinition) SqlStringFor(dialect Dialect) string {
+	return it.TableDefinition().CreateStatementFor(dialect)
+}
 
-	for k, v := range it.Columns {
-		lastElement := k == len(it.Columns)-1
-		buffer.WriteString(v.Statement(!lastElement))
-	}
+func (it *ColumnDefinition) Name() string {
+	return it.TableName
+}
+
+func (it *ColumnDefinition) CreateStatement() string {
+	return it.SqlString()
+}
+
+/*
+	The table inside schema, see SQLTableDefinition.InSchema
+ */
+func (it *ColumnDefinition) InSchema(schema string) SQLTable {
+	return it.TableDefinition().InSchema(schema)
+}
+
+func (it *ColumnDefinition) CreateStatementFor(dialect Dialect) string {
+	return it.SqlStringFor(dialect)
+}
+
+func (it *ColumnDefinition) PrimaryKeyColumns() []string {
+	return it.TableDefinition().PrimaryKeyColumns()
+}
 
-	buffer.WriteString(");")
-	return buffer.String()
+func (it *ColumnDefinition) Dependencies() []string {
+	return it.TableDefinition().Dependencies()
 }
 
+/*
+	CREATE TYPE statements of the enums used by the table
+ */
+func (it *ColumnDefinition) TypeStatements() []string {
+	return enumStatements(it.Enums)
+}
 
 func (it *ColumnDefinition) ColumnNames() []string {
-	result := make([]string, 0)
+	return it.TableDefinition().ColumnNames()
+}
 
+/*
+	The table as SQLTableDefinition, e.g. to diff it against the database
+ */
+func (it *ColumnDefinition) TableDefinition() *SQLTableDefinition {
+	builder := NewSQLTableBuilder(it.TableName)
 	for _, v := range it.Columns {
-		result = append(result, v.Name)
+		builder.WithColumn(v.tableColumn())
 	}
-
-	return result
+	builder.Definition.Enums = it.Enums
+	return builder.Build()
 }
 
-
 func (it *ColumnDefinition) WithColumnDefinition(name string, valueType string, notNull bool) *ColumnDefinition {
 	col := TableColumn{
 		Name:      name,
@@ -79,6 +109,45 @@ func (it *ColumnDefinition) AsPrimary() *ColumnDefinition {
 	return it
 }
 
+/*
+	Marks the last column as UNIQUE
+ */
+func (it *ColumnDefinition) AsUnique() *ColumnDefinition {
+	return it.withLastColumn(func(column *TableColumn) {
+		column.Unique = true
+	})
+}
+
+func (it *ColumnDefinition) WithDefault(expression string) *ColumnDefinition {
+	return it.withLastColumn(func(column *TableColumn) {
+		column.Default = expression
+	})
+}
+
+func (it *ColumnDefinition) WithCheck(expression string) *ColumnDefinition {
+	return it.withLastColumn(func(column *TableColumn) {
+		column.Check = expression
+	})
+}
+
+func (it *ColumnDefinition) References(table string, column string, onDelete string) *ColumnDefinition {
+	return it.withLastColumn(func(c *TableColumn) {
+		c.References = &ForeignKey{
+			Table:    table,
+			Column:   column,
+			OnDelete: onDelete,
+		}
+	})
+}
+
+func (it *ColumnDefinition) withLastColumn(fn func(column *TableColumn)) *ColumnDefinition {
+	lastElementPos := len(it.Columns) - 1
+	if lastElementPos >= 0 {
+		fn(&it.Columns[lastElementPos])
+	}
+	return it
+}
+
 func (builder *ColumnDefinition) WithSerialColumn(name string) *ColumnDefinition {
 	return builder.WithColumnDefinition(name, "SERIAL", false)
 }
@@ -103,6 +172,46 @@ func (builder *ColumnDefinition) WithByteAColumn(name string,  notNull bool) *Co
 	return builder.WithColumnDefinition(name, "BYTEA", notNull)
 }
 
+func (builder *ColumnDefinition) WithBigSerialColumn(name string) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, "BIGSERIAL", false)
+}
+
+func (builder *ColumnDefinition) WithTimestampTzColumn(name string, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, "TIMESTAMPTZ", notNull)
+}
+
+func (builder *ColumnDefinition) WithDateColumn(name string, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, "DATE", notNull)
+}
+
+func (builder *ColumnDefinition) WithNumericColumn(name string, precision int, scale int, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, NumericType(precision, scale), notNull)
+}
+
+func (builder *ColumnDefinition) WithUUIDColumn(name string, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, "UUID", notNull)
+}
+
+func (builder *ColumnDefinition) WithJSONBColumn(name string, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, "JSONB", notNull)
+}
+
+func (builder *ColumnDefinition) WithVarCharColumn(name string, length int, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, VarCharType(length), notNull)
+}
+
+func (builder *ColumnDefinition) WithDoublePrecisionColumn(name string, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, "DOUBLE PRECISION", notNull)
+}
+
+func (builder *ColumnDefinition) WithArrayColumn(name string, elementType string, notNull bool) *ColumnDefinition {
+	return builder.WithColumnDefinition(name, ArrayType(elementType), notNull)
+}
+
+func (builder *ColumnDefinition) WithEnumColumn(name string, enum *SQLEnum, notNull bool) *ColumnDefinition {
+	builder.Enums = appendEnum(builder.Enums, enum)
+	return builder.WithColumnDefinition(name, enum.TypeName, notNull)
+}
 
 
 
@@ -113,30 +222,33 @@ func (builder *ColumnDefinition) WithByteAColumn(name string,  notNull bool) *Co
 /////////////////////////////////////
 
 type TableColumn struct {
-	Name      string
-	Type      string
-	IsPrimary bool
-	NotNull   bool
+	Name       string
+	Type       string
+	IsPrimary  bool
+	NotNull    bool
+	Unique     bool
+	Default    string
+	Check      string
+	References *ForeignKey
 }
 
 func (column *TableColumn) Statement(withComma bool) string {
-	var buffer bytes.Buffer
-
-	buffer.WriteString(column.Name)
-	buffer.WriteString(" ")
-	buffer.WriteString(column.Type)
-
-	if column.IsPrimary {
-		buffer.WriteString(" PRIMARY KEY")
-	}
+	return column.StatementFor(Postgres, withComma)
+}
 
-	if column.NotNull {
-		buffer.WriteString(" NOT NULL")
-	}
+func (column *TableColumn) StatementFor(dialect Dialect, withComma bool) string {
+	return column.tableColumn().StatementFor(dialect, withComma)
+}
 
-	if withComma {
-		buffer.WriteString(",")
+func (column *TableColumn) tableColumn() *SQLTableColumn {
+	return &SQLTableColumn{
+		Name:       column.Name,
+		Type:       column.Type,
+		IsPrimary:  column.IsPrimary,
+		NotNULL:    column.NotNull,
+		Unique:     column.Unique,
+		Default:    column.Default,
+		Check:      column.Check,
+		References: column.References,
 	}
-
-	return buffer.String()
 }
\ No newline at end of file
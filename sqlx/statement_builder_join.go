@@ -0,0 +1,131 @@
+package sqlx
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+	Replaces the selectors given to NewSelectStatement, every selector is validated.
+	Allowed are *, table.*, columns, aggregates like CountOf(...) and aliases built with As
+ */
+func (it *StatementBuilder) Select(selectors ...string) *StatementBuilder {
+	builder := it.copy()
+	builder.columns = append(make([]string, 0, len(selectors)), selectors...)
+	return builder
+}
+
+/*
+	INNER JOIN table ON on[0] AND on[1] ...
+ */
+func (it *StatementBuilder) Join(table string, on ...*JoinCondition) *StatementBuilder {
+	return it.addJoin("JOIN", table, on)
+}
+
+/*
+	LEFT JOIN table ON on[0] AND on[1] ...
+ */
+func (it *StatementBuilder) LeftJoin(table string, on ...*JoinCondition) *StatementBuilder {
+	return it.addJoin("LEFT JOIN", table, on)
+}
+
+func (it *StatementBuilder) GroupBy(columns ...string) *StatementBuilder {
+	builder := it.copy()
+	builder.groupBy = append(builder.groupBy, columns...)
+	return builder
+}
+
+/*
+	Adds a parameterized HAVING condition, labels may be aggregates, e.g.
+	Having(GreaterThanCondition(CountOf("*"), 5))
+ */
+func (it *StatementBuilder) Having(condition Condition) *StatementBuilder {
+	if condition == nil {
+		return it
+	}
+
+	builder := it.copy()
+	builder.having = append(builder.having, condition)
+	return builder
+}
+
+func (it *StatementBuilder) addJoin(kind string, table string, on []*JoinCondition) *StatementBuilder {
+	if len(on) == 0 {
+		return it.withError(fmt.Errorf("%v %v without ON condition", kind, table))
+	}
+
+	builder := it.copy()
+	builder.joins = append(builder.joins, &join{
+		kind:  kind,
+		table: table,
+		on:    on,
+	})
+	return builder
+}
+
+/*
+	left = right, both being (qualified) columns
+ */
+type JoinCondition struct {
+	Left  string
+	Right string
+}
+
+func On(left string, right string) *JoinCondition {
+	return &JoinCondition{
+		Left:  left,
+		Right: right,
+	}
+}
+
+type join struct {
+	kind  string
+	table string
+	on    []*JoinCondition
+}
+
+func (it *join) render(ctx *renderContext) string {
+	conditions := make([]string, len(it.on))
+	for k, v := range it.on {
+		conditions[k] = fmt.Sprintf("%v = %v", ctx.identifier(v.Left), ctx.identifier(v.Right))
+	}
+
+	return fmt.Sprintf(" %v %v ON %v", it.kind, ctx.identifier(it.table), strings.Join(conditions, " AND "))
+}
+
+//////////////////////////////////////
+//
+// Aggregates
+//
+/////////////////////////////////////
+
+func CountOf(column string) string {
+	return fmt.Sprintf("count(%v)", column)
+}
+
+func CountDistinctOf(column string) string {
+	return fmt.Sprintf("count(DISTINCT %v)", column)
+}
+
+func SumOf(column string) string {
+	return fmt.Sprintf("sum(%v)", column)
+}
+
+func AvgOf(column string) string {
+	return fmt.Sprintf("avg(%v)", column)
+}
+
+func MinOf(column string) string {
+	return fmt.Sprintf("min(%v)", column)
+}
+
+func MaxOf(column string) string {
+	return fmt.Sprintf("max(%v)", column)
+}
+
+/*
+	selector AS alias
+ */
+func As(selector string, alias string) string {
+	return fmt.Sprintf("%v AS %v", selector, alias)
+}
@@ -0,0 +1,129 @@
+package sqlx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type keyset struct {
+	columns []string
+	desc    bool
+	cursor  string
+}
+
+/*
+	Keyset pagination on columns, which should be unique together, e.g. created,id.
+	Orders by columns and, if cursor is not empty, only returns rows after the row it was encoded from:
+	(created, id) > ($1, $2). Use with AddLimit and read the next cursor with SQLDB.SelectKeyset
+ */
+func (it *StatementBuilder) Keyset(columns []string, desc bool, cursor string) *StatementBuilder {
+	if len(columns) == 0 {
+		return it.withError(fmt.Errorf("keyset pagination without columns"))
+	}
+
+	builder := it.copy()
+	builder.keyset = &keyset{
+		columns: append(make([]string, 0, len(columns)), columns...),
+		desc:    desc,
+		cursor:  cursor,
+	}
+	return builder
+}
+
+func (it *keyset) condition() (Condition, error) {
+	if it.cursor == "" {
+		return nil, nil
+	}
+
+	values, err := DecodeCursor(it.cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) != len(it.columns) {
+		return nil, fmt.Errorf("cursor has %v values, keyset has %v columns", len(values), len(it.columns))
+	}
+
+	operator := ">"
+	if it.desc {
+		operator = "<"
+	}
+
+	return &rowComparisonCondition{
+		labels:   it.columns,
+		operator: operator,
+		values:   values,
+	}, nil
+}
+
+func (it *keyset) orderBy(ctx *renderContext) string {
+	direction := "ASC"
+	if it.desc {
+		direction = "DESC"
+	}
+
+	parts := make([]string, len(it.columns))
+	for k, v := range it.columns {
+		parts[k] = fmt.Sprintf("%v %v", ctx.identifier(v), direction)
+	}
+	return strings.Join(parts, ",")
+}
+
+/*
+	(l1, l2) > ($1, $2)
+ */
+type rowComparisonCondition struct {
+	labels   []string
+	operator string
+	values   []interface{}
+}
+
+func (it *rowComparisonCondition) render(ctx *renderContext) string {
+	labels := make([]string, len(it.labels))
+	for k, v := range it.labels {
+		labels[k] = ctx.identifier(v)
+	}
+
+	placeholders := make([]string, len(it.values))
+	for k, v := range it.values {
+		placeholders[k] = ctx.bind(v)
+	}
+
+	return fmt.Sprintf("(%v) %v (%v)", strings.Join(labels, ", "), it.operator, strings.Join(placeholders, ", "))
+}
+
+/*
+	Opaque token of the key values of the last row of a page
+ */
+func EncodeCursor(values ...interface{}) (string, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+/*
+	Numbers are decoded as json.Number to keep the precision of 64 bit keys
+ */
+func DecodeCursor(cursor string) ([]interface{}, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+
+	values := make([]interface{}, 0)
+	err = decoder.Decode(&values)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor: %v", err)
+	}
+
+	return values, nil
+}
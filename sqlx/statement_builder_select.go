@@ -1,32 +1,29 @@
 package sqlx
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/Leondroids/gox"
+	"github.com/ellsol/gox/typex"
+	"regexp"
+	"strings"
 )
 
+/*
+	selectors are used as given and must never contain user input, tableName is validated
+ */
 func NewSelectStatement(selectors string, tableName string) (*StatementBuilder) {
 	return &StatementBuilder{
-		statement:         fmt.Sprintf("SELECT %v FROM %v", selectors, tableName),
-		conditionParams:   make([]interface{}, 0),
-		conditionPosition: 1,
+		selectors:  selectors,
+		table:      tableName,
+		conditions: make([]Condition, 0),
 	}
 }
 
+/*
+	Matches rows whose conditionLabel starts with one of the characters of conditionValue
+ */
 func (it *StatementBuilder) AddLikeCondition(conditionLabel string, conditionValue string) *StatementBuilder {
-	newStatement := ""
-	if !it.hasOneCondition {
-		newStatement = fmt.Sprintf("%v WHERE %v ~ '^[%v]'", it.statement, conditionLabel, conditionValue)
-	} else {
-		newStatement = fmt.Sprintf("%v AND %v ~ '^[%v]'", it.statement, conditionLabel, conditionValue)
-	}
-
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: it.conditionPosition,
-		conditionParams:   it.conditionParams,
-		hasOneCondition:   true,
-	}
+	return it.addCondition(LikeCondition(conditionLabel, conditionValue))
 }
 
 func (it *StatementBuilder) AddInCondition(conditionLabel string, values []string) *StatementBuilder {
@@ -34,30 +31,12 @@ func (it *StatementBuilder) AddInCondition(conditionLabel string, values []strin
 		return it
 	}
 
-	conditionPosCounter := it.conditionPosition - 1
-	methodPlaceholder := gox.CommaSeparatedString(gox.MapStringListWithPos(values, func(key int, value string) string {
-		conditionPosCounter++
-		return fmt.Sprintf("$%v", conditionPosCounter)
-	}))
-
-	newStatement := ""
-	if !it.hasOneCondition {
-		newStatement = fmt.Sprintf("%v WHERE %v IN (%v)", it.statement, conditionLabel, methodPlaceholder)
-	} else {
-		newStatement = fmt.Sprintf("%v AND %v IN (%v)", it.statement, conditionLabel, methodPlaceholder)
-	}
-
-	params := it.conditionParams
-	for _, v := range values {
-		params = append(params, v)
+	params := make([]interface{}, len(values))
+	for k, v := range values {
+		params[k] = v
 	}
 
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: conditionPosCounter + 1,
-		conditionParams:   params,
-		hasOneCondition:   true,
-	}
+	return it.addCondition(InCondition(conditionLabel, params))
 }
 
 func (it *StatementBuilder) MaybeAddEqualStringCondition(conditionLabel string, conditionValue string) *StatementBuilder {
@@ -75,22 +54,7 @@ func (it *StatementBuilder) FilterBoolean(conditionLabel string, conditionValue
 }
 
 func (it *StatementBuilder) AddEqualCondition(conditionLabel string, conditionValue interface{}) *StatementBuilder {
-	newStatement := ""
-	if !it.hasOneCondition {
-		newStatement = fmt.Sprintf("%v WHERE %v = $%v", it.statement, conditionLabel, it.conditionPosition)
-	} else {
-		newStatement = fmt.Sprintf("%v AND %v = $%v", it.statement, conditionLabel, it.conditionPosition)
-	}
-
-	params := it.conditionParams
-	params = append(params, conditionValue)
-
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: it.conditionPosition + 1,
-		conditionParams:   params,
-		hasOneCondition:   true,
-	}
+	return it.addCondition(EqualCondition(conditionLabel, conditionValue))
 }
 
 func (it *StatementBuilder) AddDateRange(conditionLabel string, dateFrom int64, dateTo int64) *StatementBuilder {
@@ -131,45 +95,60 @@ func (it *StatementBuilder) AddInt64Range(conditionLabel string, from int64, to
 }
 
 func (it *StatementBuilder) AddRange(conditionLabel string, valuesFrom interface{}, valuesTo interface{}) *StatementBuilder {
+	return it.addCondition(RangeCondition(conditionLabel, valuesFrom, valuesTo))
+}
 
-	newStatement := ""
-	if !it.hasOneCondition {
-		newStatement = fmt.Sprintf("%v WHERE %v BETWEEN $%v AND $%v", it.statement, conditionLabel, it.conditionPosition, it.conditionPosition+1)
-	} else {
-		newStatement = fmt.Sprintf("%v AND %v BETWEEN $%v AND $%v", it.statement, conditionLabel, it.conditionPosition, it.conditionPosition+1)
+/*
+	Adds any condition, e.g. a group built with AnyOf, AllOf or Not. nil is ignored
+ */
+func (it *StatementBuilder) Where(condition Condition) *StatementBuilder {
+	if condition == nil {
+		return it
 	}
 
-	params := it.conditionParams
-	params = append(params, valuesFrom)
-	params = append(params, valuesTo)
-
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: it.conditionPosition + 2,
-		conditionParams:   params,
-		hasOneCondition:   true,
-	}
+	return it.addCondition(condition)
 }
 
+/*
+	Adds a sort key, calling it again adds further keys in order of the calls
+ */
 func (it *StatementBuilder) OrderBy(orderBy *StatementOrderBy) *StatementBuilder {
 	if orderBy == nil {
 		return it
 	}
 
-	direction := "DESC"
+	builder := it.copy()
+	builder.orderBy = append(builder.orderBy, orderBy)
+	return builder
+}
 
-	if !orderBy.DirectionDesc {
-		direction = "ASC"
+func (it *StatementBuilder) OrderByAll(orderBy []*StatementOrderBy) *StatementBuilder {
+	builder := it
+	for _, v := range orderBy {
+		builder = builder.OrderBy(v)
 	}
+	return builder
+}
 
-	newStatement := fmt.Sprintf("%v ORDER BY %v %v", it.statement, orderBy.By, direction)
+/*
+	Like OrderBy, but fails if orderBy.By is not part of whitelist. The column is replaced by the one
+	registered in whitelist, so user supplied sort parameters can be passed through
+ */
+func (it *StatementBuilder) OrderByWhitelisted(orderBy *StatementOrderBy, whitelist *SortWhitelist) *StatementBuilder {
+	if orderBy == nil {
+		return it
+	}
 
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: it.conditionPosition,
-		conditionParams:   it.conditionParams,
-		hasOneCondition:   true,
+	column, err := whitelist.Resolve(orderBy.By)
+	if err != nil {
+		return it.withError(err)
 	}
+
+	return it.OrderBy(&StatementOrderBy{
+		By:            column,
+		DirectionDesc: orderBy.DirectionDesc,
+		Nulls:         orderBy.Nulls,
+	})
 }
 
 func (it *StatementBuilder) AddOffset(value int) *StatementBuilder {
@@ -177,17 +156,9 @@ func (it *StatementBuilder) AddOffset(value int) *StatementBuilder {
 		return it
 	}
 
-	newStatement := fmt.Sprintf("%v OFFSET $%v", it.statement, it.conditionPosition)
-
-	params := it.conditionParams
-	params = append(params, value)
-
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: it.conditionPosition + 1,
-		conditionParams:   params,
-		hasOneCondition:   true,
-	}
+	builder := it.copy()
+	builder.offset = value
+	return builder
 }
 
 func (it *StatementBuilder) AddLimit(value int) *StatementBuilder {
@@ -195,31 +166,618 @@ func (it *StatementBuilder) AddLimit(value int) *StatementBuilder {
 		return it
 	}
 
-	newStatement := fmt.Sprintf("%v LIMIT $%v", it.statement, it.conditionPosition)
+	builder := it.copy()
+	builder.limit = value
+	return builder
+}
+
+/*
+	Legacy rendering without validation, labels like lower(name) are passed through as they are.
+	Failures like a sort key rejected by OrderByWhitelisted or an invalid cursor are logged and the
+	affected part is left out. Placeholders are rewritten for the dialect of the builder, see WithDialect
+ */
+func (it *StatementBuilder) GetStatementAndParams() (string, []interface{}) {
+	if it.err != nil {
+		logMsg(fmt.Sprintf("ignoring invalid select statement part: %v", it.err))
+	}
 
-	params := it.conditionParams
-	params = append(params, value)
+	statement, params, _ := it.render(true)
+	return statement, params
+}
 
-	return &StatementBuilder{
-		statement:         newStatement,
-		conditionPosition: it.conditionPosition + 1,
-		conditionParams:   params,
-		hasOneCondition:   true,
+/*
+	Renders the statement, fails if any of the identifiers used is invalid. The checked way to render
+	a builder, e.g. with user supplied sort parameters or cursors
+ */
+func (it *StatementBuilder) Build() (string, []interface{}, error) {
+	if it.err != nil {
+		return "", nil, it.err
 	}
+
+	return it.render(false)
 }
 
-func (it *StatementBuilder) GetStatementAndParams() (string, []interface{}) {
-	return it.statement, it.conditionParams
+// lenient skips identifier validation and leaves out anything failing, it never returns an error
+func (it *StatementBuilder) render(lenient bool) (string, []interface{}, error) {
+	ctx := newRenderContext(it.dialect, it.quoteIdentifiers)
+	ctx.lenient = lenient
+
+	selectors := it.selectors
+	if len(it.columns) > 0 {
+		selectors = strings.Join(typex.MapStringList(it.columns, ctx.selector), ",")
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("SELECT %v FROM %v", selectors, ctx.identifier(it.table)))
+
+	for _, v := range it.joins {
+		buffer.WriteString(v.render(ctx))
+	}
+
+	conditions := it.conditions
+	orderBy := it.orderBy
+	if it.keyset != nil {
+		if len(orderBy) > 0 {
+			err := fmt.Errorf("keyset pagination can not be combined with OrderBy")
+			if !lenient {
+				return "", nil, err
+			}
+			logMsg(err.Error())
+			orderBy = nil
+		}
+
+		keysetCondition, err := it.keyset.condition()
+		if err != nil {
+			if !lenient {
+				return "", nil, err
+			}
+			// starts from the first page
+			logMsg(err.Error())
+		}
+
+		if keysetCondition != nil {
+			conditions = append(append(make([]Condition, 0, len(conditions)+1), conditions...), keysetCondition)
+		}
+	}
+
+	buffer.WriteString(renderWhere(ctx, conditions))
+
+	if len(it.groupBy) > 0 {
+		buffer.WriteString(" GROUP BY ")
+		buffer.WriteString(strings.Join(typex.MapStringList(it.groupBy, ctx.identifier), ","))
+	}
+
+	for k, v := range it.having {
+		if k == 0 {
+			buffer.WriteString(" HAVING ")
+		} else {
+			buffer.WriteString(" AND ")
+		}
+		buffer.WriteString(v.render(ctx))
+	}
+
+	if len(orderBy) > 0 {
+		keys := make([]string, len(orderBy))
+		for k, v := range orderBy {
+			keys[k] = v.render(ctx)
+		}
+
+		buffer.WriteString(" ORDER BY " + strings.Join(keys, ","))
+	}
+
+	if it.keyset != nil {
+		buffer.WriteString(" ORDER BY " + it.keyset.orderBy(ctx))
+	}
+
+	if it.offset != 0 {
+		buffer.WriteString(fmt.Sprintf(" OFFSET %v", ctx.bind(it.offset)))
+	}
+
+	if it.limit != 0 {
+		buffer.WriteString(fmt.Sprintf(" LIMIT %v", ctx.bind(it.limit)))
+	}
+
+	if ctx.err != nil {
+		return "", nil, ctx.err
+	}
+
+	statement := buffer.String()
+	if it.countSubquery {
+		statement = fmt.Sprintf("SELECT count(*) FROM (%v) AS counted", statement)
+	}
+
+	statement, params := Rebind(it.dialect, statement, ctx.params)
+	return statement, params, nil
+}
+
+/*
+	Counting statement with the same joins and conditions, ignoring order, keyset, offset and limit.
+	Grouped statements and selections changing the number of rows, e.g. DISTINCT or aggregates, are counted as subquery
+ */
+func (it *StatementBuilder) CountStatement() *StatementBuilder {
+	builder := it.copy()
+	builder.orderBy = make([]*StatementOrderBy, 0)
+	builder.keyset = nil
+	builder.offset = 0
+	builder.limit = 0
+
+	builder.countSubquery = len(builder.groupBy) > 0 || len(builder.having) > 0 || !builder.plainSelection()
+	if !builder.countSubquery {
+		builder.selectors = "count(*)"
+		builder.columns = make([]string, 0)
+	}
+
+	return builder
+}
+
+// whether the selection returns one row per matching row, like * or plain columns with optional aliases
+func (it *StatementBuilder) plainSelection() bool {
+	selectors := it.columns
+	if len(selectors) == 0 {
+		selectors = strings.Split(it.selectors, ",")
+	}
+
+	for _, v := range selectors {
+		selector := strings.TrimSpace(v)
+		if match := selectorAliasPattern.FindStringSubmatch(selector); match != nil {
+			selector = match[1]
+		}
+
+		if selector != "*" && ValidateIdentifier(strings.TrimSuffix(selector, ".*")) != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+	Dialect used by GetStatementAndParams, Postgres if not set
+ */
+func (it *StatementBuilder) WithDialect(dialect Dialect) *StatementBuilder {
+	builder := it.copy()
+	builder.dialect = dialect
+	return builder
+}
+
+/*
+	Quotes table and column names using the dialect, e.g. for names colliding with keywords
+ */
+func (it *StatementBuilder) WithQuotedIdentifiers() *StatementBuilder {
+	builder := it.copy()
+	builder.quoteIdentifiers = true
+	return builder
+}
+
+func (it *StatementBuilder) addCondition(condition Condition) *StatementBuilder {
+	builder := it.copy()
+	builder.conditions = append(builder.conditions, condition)
+	return builder
+}
+
+func (it *StatementBuilder) withError(err error) *StatementBuilder {
+	builder := it.copy()
+	if builder.err == nil {
+		builder.err = err
+	}
+	return builder
+}
+
+// copy shares nothing mutable with it, so older builders stay untouched
+func (it *StatementBuilder) copy() *StatementBuilder {
+	builder := *it
+	builder.columns = append(make([]string, 0, len(it.columns)), it.columns...)
+	builder.joins = append(make([]*join, 0, len(it.joins)+1), it.joins...)
+	builder.conditions = append(make([]Condition, 0, len(it.conditions)+1), it.conditions...)
+	builder.groupBy = append(make([]string, 0, len(it.groupBy)), it.groupBy...)
+	builder.having = append(make([]Condition, 0, len(it.having)+1), it.having...)
+	builder.orderBy = append(make([]*StatementOrderBy, 0, len(it.orderBy)+1), it.orderBy...)
+	return &builder
 }
 
 type StatementBuilder struct {
-	statement         string
-	conditionPosition int
-	conditionParams   []interface{}
-	hasOneCondition   bool
+	selectors        string
+	columns          []string
+	table            string
+	joins            []*join
+	conditions       []Condition
+	groupBy          []string
+	having           []Condition
+	orderBy          []*StatementOrderBy
+	keyset           *keyset
+	offset           int
+	limit            int
+	dialect          Dialect
+	quoteIdentifiers bool
+	countSubquery     bool
+	err              error
 }
 
 type StatementOrderBy struct {
 	By            string
 	DirectionDesc bool
+	Nulls         NullsOrder
+}
+
+type NullsOrder int
+
+const (
+	// database default, NULLS LAST for ASC and NULLS FIRST for DESC in postgres
+	NullsDefault NullsOrder = iota
+	NullsFirst
+	NullsLast
+)
+
+func (it *StatementOrderBy) render(ctx *renderContext) string {
+	direction := "DESC"
+
+	if !it.DirectionDesc {
+		direction = "ASC"
+	}
+
+	switch it.Nulls {
+	case NullsFirst:
+		return fmt.Sprintf("%v %v NULLS FIRST", ctx.label(it.By), direction)
+	case NullsLast:
+		return fmt.Sprintf("%v %v NULLS LAST", ctx.label(it.By), direction)
+	}
+
+	return fmt.Sprintf("%v %v", ctx.label(it.By), direction)
+}
+
+/*
+	Parses a sort parameter like -created,name into sort keys, a leading - sorts descending, + or nothing ascending.
+	Every name has to be part of whitelist and is replaced by its column
+ */
+func ParseSortParameter(parameter string, whitelist *SortWhitelist) ([]*StatementOrderBy, error) {
+	result := make([]*StatementOrderBy, 0)
+
+	for _, v := range strings.Split(parameter, ",") {
+		name := strings.TrimSpace(v)
+		if name == "" {
+			continue
+		}
+
+		desc := false
+		if strings.HasPrefix(name, "-") {
+			desc = true
+			name = name[1:]
+		} else if strings.HasPrefix(name, "+") {
+			name = name[1:]
+		}
+
+		column, err := whitelist.Resolve(name)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &StatementOrderBy{
+			By:            column,
+			DirectionDesc: desc,
+		})
+	}
+
+	return result, nil
+}
+
+//////////////////////////////////////
+//
+// Conditions
+//
+/////////////////////////////////////
+
+/*
+	Part of a WHERE clause, placeholders are numbered when the statement is rendered
+ */
+type Condition interface {
+	render(ctx *renderContext) string
+}
+
+/*
+	Anything rendering to a statement and its params, e.g. StatementBuilder or UpdateStatementBuilder
+ */
+type Statement interface {
+	Build() (string, []interface{}, error)
+}
+
+func renderWhere(ctx *renderContext, conditions []Condition) string {
+	parts := make([]string, len(conditions))
+	for k, v := range conditions {
+		parts[k] = v.render(ctx)
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(parts, " AND ")
+}
+
+func newRenderContext(dialect Dialect, quoteIdentifiers bool) *renderContext {
+	return &renderContext{
+		position:         1,
+		params:           make([]interface{}, 0),
+		dialect:          dialect,
+		quoteIdentifiers: quoteIdentifiers,
+	}
+}
+
+type renderContext struct {
+	position         int
+	params           []interface{}
+	dialect          Dialect
+	quoteIdentifiers bool
+	// identifiers are not validated, see GetStatementAndParams
+	lenient bool
+	err     error
+}
+
+// adds value to the params and returns its placeholder
+func (it *renderContext) bind(value interface{}) string {
+	placeholder := fmt.Sprintf("$%v", it.position)
+	it.params = append(it.params, value)
+	it.position++
+	return placeholder
+}
+
+var aggregatePattern = regexp.MustCompile(`^(?i)(count|sum|avg|min|max)\((distinct )?(.+)\)$`)
+
+// like identifier, but also accepts aggregates like count(*) or sum(column)
+func (it *renderContext) label(label string) string {
+	match := aggregatePattern.FindStringSubmatch(label)
+	if match == nil {
+		return it.identifier(label)
+	}
+
+	argument := match[3]
+	if argument != "*" || match[2] != "" {
+		argument = it.identifier(argument)
+	}
+
+	return fmt.Sprintf("%v(%v%v)", strings.ToLower(match[1]), strings.ToUpper(match[2]), argument)
+}
+
+var selectorAliasPattern = regexp.MustCompile(`^(?i)(.+) AS ([^ ]+)$`)
+
+// validates selectors like *, table.*, column, aggregates, each with an optional AS alias
+func (it *renderContext) selector(selector string) string {
+	if match := selectorAliasPattern.FindStringSubmatch(selector); match != nil {
+		return fmt.Sprintf("%v AS %v", it.selector(match[1]), it.identifier(match[2]))
+	}
+
+	if selector == "*" {
+		return selector
+	}
+
+	if strings.HasSuffix(selector, ".*") {
+		return it.identifier(strings.TrimSuffix(selector, ".*")) + ".*"
+	}
+
+	return it.label(selector)
+}
+
+// validates and, if requested, quotes identifier
+func (it *renderContext) identifier(identifier string) string {
+	err := ValidateIdentifier(identifier)
+	if err != nil {
+		if it.err == nil && !it.lenient {
+			it.err = err
+		}
+		return identifier
+	}
+
+	if !it.quoteIdentifiers {
+		return identifier
+	}
+
+	dialect := it.dialect
+	if dialect == nil {
+		dialect = Postgres
+	}
+	return dialect.QuoteIdentifier(identifier)
+}
+
+func EqualCondition(conditionLabel string, conditionValue interface{}) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "=",
+		value:    conditionValue,
+	}
+}
+
+/*
+	Never matches if values is empty
+ */
+func InCondition(conditionLabel string, values []interface{}) Condition {
+	return &inCondition{
+		label:  conditionLabel,
+		values: values,
+	}
+}
+
+func RangeCondition(conditionLabel string, valuesFrom interface{}, valuesTo interface{}) Condition {
+	return &rangeCondition{
+		label: conditionLabel,
+		from:  valuesFrom,
+		to:    valuesTo,
+	}
+}
+
+/*
+	Matches rows whose conditionLabel starts with one of the characters of conditionValue
+ */
+func LikeCondition(conditionLabel string, conditionValue string) Condition {
+	return &comparisonCondition{
+		label:    conditionLabel,
+		operator: "~",
+		value:    fmt.Sprintf("^[%v]", escapeRegexBracket(conditionValue)),
+	}
+}
+
+/*
+	(c1 OR c2 ...), nil conditions are skipped, never matches if no condition is left
+ */
+func AnyOf(conditions ...Condition) Condition {
+	return &groupCondition{
+		operator:   "OR",
+		empty:      "FALSE",
+		conditions: conditions,
+	}
+}
+
+/*
+	(c1 AND c2 ...), nil conditions are skipped, always matches if no condition is left
+ */
+func AllOf(conditions ...Condition) Condition {
+	return &groupCondition{
+		operator:   "AND",
+		empty:      "TRUE",
+		conditions: conditions,
+	}
+}
+
+func Not(condition Condition) Condition {
+	return &notCondition{
+		condition: condition,
+	}
+}
+
+type groupCondition struct {
+	operator   string
+	empty      string
+	conditions []Condition
+}
+
+func (it *groupCondition) render(ctx *renderContext) string {
+	parts := make([]string, 0, len(it.conditions))
+	for _, v := range it.conditions {
+		if v != nil {
+			parts = append(parts, v.render(ctx))
+		}
+	}
+
+	if len(parts) == 0 {
+		return it.empty
+	}
+
+	return "(" + strings.Join(parts, " "+it.operator+" ") + ")"
+}
+
+type notCondition struct {
+	condition Condition
+}
+
+func (it *notCondition) render(ctx *renderContext) string {
+	if it.condition == nil {
+		return "FALSE"
+	}
+	return fmt.Sprintf("NOT (%v)", it.condition.render(ctx))
+}
+
+type comparisonCondition struct {
+	label    string
+	operator string
+	value    interface{}
+}
+
+func (it *comparisonCondition) render(ctx *renderContext) string {
+	return fmt.Sprintf("%v %v %v", ctx.label(it.label), it.operator, ctx.bind(it.value))
+}
+
+type inCondition struct {
+	label  string
+	values []interface{}
+	not    bool
+}
+
+func (it *inCondition) render(ctx *renderContext) string {
+	if len(it.values) == 0 {
+		if it.not {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+
+	placeholders := make([]string, len(it.values))
+	for k, v := range it.values {
+		placeholders[k] = ctx.bind(v)
+	}
+
+	operator := "IN"
+	if it.not {
+		operator = "NOT IN"
+	}
+
+	return fmt.Sprintf("%v %v (%v)", ctx.label(it.label), operator, typex.CommaSeparatedString(placeholders))
+}
+
+type rangeCondition struct {
+	label string
+	from  interface{}
+	to    interface{}
+}
+
+func (it *rangeCondition) render(ctx *renderContext) string {
+	label := ctx.label(it.label)
+	from := ctx.bind(it.from)
+	return fmt.Sprintf("%v BETWEEN %v AND %v", label, from, ctx.bind(it.to))
+}
+
+func escapeRegexBracket(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `]`, `\]`, `^`, `\^`, `-`, `\-`)
+	return replacer.Replace(value)
+}
+
+//////////////////////////////////////
+//
+// Identifiers
+//
+/////////////////////////////////////
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*){0,2}$`)
+
+/*
+	Accepts plain and qualified names like column, table.column or schema.table.column, everything else is rejected
+	so it can not be abused to inject sql
+ */
+func ValidateIdentifier(identifier string) error {
+	if !identifierPattern.MatchString(identifier) {
+		return fmt.Errorf("invalid identifier %q", identifier)
+	}
+	return nil
+}
+
+/*
+	Maps sort names accepted from the outside, e.g. http query parameters, to table columns
+ */
+type SortWhitelist struct {
+	columns map[string]string
+}
+
+func NewSortWhitelist(columns ...string) *SortWhitelist {
+	whitelist := &SortWhitelist{
+		columns: make(map[string]string),
+	}
+
+	for _, v := range columns {
+		whitelist.WithAlias(v, v)
+	}
+
+	return whitelist
+}
+
+/*
+	Allows sorting by name, which is mapped to column
+ */
+func (it *SortWhitelist) WithAlias(name string, column string) *SortWhitelist {
+	it.columns[name] = column
+	return it
+}
+
+func (it *SortWhitelist) Resolve(name string) (string, error) {
+	column, ok := it.columns[name]
+	if !ok {
+		return "", fmt.Errorf("sorting by %q is not allowed", name)
+	}
+	return column, nil
 }
@@ -55,3 +55,340 @@ func TestStatementBuilder(t *testing.T) {
 		return
 	}
 }
+
+func TestStatementBuilderLikeIsParameterized(t *testing.T) {
+	stb, params := NewSelectStatement("*", "tablename").
+		AddLikeCondition("name", "ab]'; DROP TABLE x; --").
+		GetStatementAndParams()
+
+	if testx.CompareString("statement", "SELECT * FROM tablename WHERE name ~ $1", stb, t) {
+		return
+	}
+
+	if len(params) != 1 || params[0] != `^[ab\]'; DROP TABLE x; \-\-]` {
+		t.Errorf("Param is wrong [Actual: %v]", params)
+		return
+	}
+}
+
+func TestStatementBuilderRejectsInvalidIdentifiers(t *testing.T) {
+	_, _, err := NewSelectStatement("*", "tablename").
+		AddEqualCondition("label; DROP TABLE tablename", 1).
+		Build()
+	if err == nil {
+		t.Errorf("expected error for invalid condition label")
+		return
+	}
+
+	_, _, err = NewSelectStatement("*", "tablename").
+		OrderBy(&StatementOrderBy{By: "created DESC; --"}).
+		Build()
+	if err == nil {
+		t.Errorf("expected error for invalid order by")
+		return
+	}
+
+	stb, _, err := NewSelectStatement("*", "public.user").
+		AddEqualCondition("order", 1).
+		WithQuotedIdentifiers().
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("quoted statement", `SELECT * FROM "public"."user" WHERE "order" = $1`, stb, t) {
+		return
+	}
+}
+
+func TestStatementBuilderOrderByWhitelisted(t *testing.T) {
+	whitelist := NewSortWhitelist("name").WithAlias("created", "created_at")
+
+	stb, _, err := NewSelectStatement("*", "tablename").
+		OrderByWhitelisted(&StatementOrderBy{By: "created", DirectionDesc: true}, whitelist).
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("statement", "SELECT * FROM tablename ORDER BY created_at DESC", stb, t) {
+		return
+	}
+
+	_, _, err = NewSelectStatement("*", "tablename").
+		OrderByWhitelisted(&StatementOrderBy{By: "password"}, whitelist).
+		Build()
+	if err == nil {
+		t.Errorf("expected error for column not in whitelist")
+	}
+}
+
+func TestStatementBuilderConditionGroups(t *testing.T) {
+	stb, params := NewSelectStatement("*", "tablename").
+		AddEqualCondition("label", 1).
+		Where(AnyOf(
+			EqualCondition("a", 2),
+			AllOf(EqualCondition("b", 3), Not(InCondition("c", []interface{}{4, 5}))),
+			nil,
+		)).
+		AddInCondition("d", []string{"p1"}).
+		AddLimit(10).
+		GetStatementAndParams()
+
+	expectedStatement := "SELECT * FROM tablename WHERE label = $1 AND (a = $2 OR (b = $3 AND NOT (c IN ($4,$5)))) AND d IN ($6) LIMIT $7"
+	if testx.CompareString("statement", expectedStatement, stb, t) {
+		return
+	}
+
+	expectedParams := []interface{}{1, 2, 3, 4, 5, "p1", 10}
+	if testx.CompareInt("params", len(expectedParams), len(params), t) {
+		return
+	}
+
+	for k, v := range expectedParams {
+		if params[k] != v {
+			t.Errorf("Param[%v] is wrong [Expected %v, Actual: %v]", k, v, params[k])
+			return
+		}
+	}
+
+	stb, _ = NewSelectStatement("*", "tablename").Where(AnyOf()).GetStatementAndParams()
+	if testx.CompareString("empty group", "SELECT * FROM tablename WHERE FALSE", stb, t) {
+		return
+	}
+}
+
+func TestStatementBuilderComparisons(t *testing.T) {
+	stb, params := NewSelectStatement("*", "tablename").
+		AddLessThanCondition("a", 1).
+		AddLessOrEqualCondition("b", 2).
+		AddGreaterThanCondition("c", 3).
+		AddGreaterOrEqualCondition("d", 4).
+		AddNotEqualCondition("e", 5).
+		AddIsNullCondition("f").
+		AddIsNotNullCondition("g").
+		AddNotInCondition("h", []string{"p1", "p2"}).
+		AddILikeCondition("i", "50%_off").
+		AddArrayContainsCondition("j", []string{"x"}).
+		AddArrayOverlapCondition("k", []int64{1, 2}).
+		GetStatementAndParams()
+
+	expectedStatement := "SELECT * FROM tablename WHERE a < $1 AND b <= $2 AND c > $3 AND d >= $4 AND e <> $5 AND f IS NULL AND g IS NOT NULL AND h NOT IN ($6,$7) AND i ILIKE $8 AND j @> $9 AND k && $10"
+	if testx.CompareString("statement", expectedStatement, stb, t) {
+		return
+	}
+
+	if testx.CompareInt("params", 10, len(params), t) {
+		return
+	}
+
+	if params[4] != 5 || params[6] != "p2" {
+		t.Errorf("Params are wrong [Actual: %v]", params)
+		return
+	}
+
+	if params[7] != `%50\%\_off%` {
+		t.Errorf("Param[7] is wrong [Expected %%50\\%%\\_off%%, Actual: %v]", params[7])
+		return
+	}
+}
+
+func TestStatementBuilderJoinGroupByHaving(t *testing.T) {
+	stb, params, err := NewSelectStatement("", "users").
+		AddLimit(10).
+		Having(GreaterThanCondition(CountOf("orders.id"), 5)).
+		Select("users.name", As(CountOf("orders.id"), "order_count"), As(SumOf("orders.total"), "total")).
+		GroupBy("users.name").
+		AddEqualCondition("users.active", true).
+		LeftJoin("orders", On("orders.user_id", "users.id")).
+		OrderBy(&StatementOrderBy{By: CountOf("orders.id"), DirectionDesc: true}).
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedStatement := "SELECT users.name,count(orders.id) AS order_count,sum(orders.total) AS total FROM users LEFT JOIN orders ON orders.user_id = users.id WHERE users.active = $1 GROUP BY users.name HAVING count(orders.id) > $2 ORDER BY count(orders.id) DESC LIMIT $3"
+	if testx.CompareString("statement", expectedStatement, stb, t) {
+		return
+	}
+
+	if len(params) != 3 || params[0] != true || params[1] != 5 || params[2] != 10 {
+		t.Errorf("Params are wrong [Actual: %v]", params)
+		return
+	}
+
+	_, _, err = NewSelectStatement("", "users").Select("name; DROP TABLE users").Build()
+	if err == nil {
+		t.Errorf("expected error for invalid selector")
+	}
+}
+
+func TestStatementBuilderKeyset(t *testing.T) {
+	stb, params := NewSelectStatement("*", "tablename").
+		AddEqualCondition("owner", "max").
+		Keyset([]string{"created", "id"}, true, "").
+		AddLimit(20).
+		GetStatementAndParams()
+
+	if testx.CompareString("first page", "SELECT * FROM tablename WHERE owner = $1 ORDER BY created DESC,id DESC LIMIT $2", stb, t) {
+		return
+	}
+
+	cursor, err := EncodeCursor(int64(1546300800), int64(9007199254740993))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	stb, params = NewSelectStatement("*", "tablename").
+		AddEqualCondition("owner", "max").
+		Keyset([]string{"created", "id"}, false, cursor).
+		AddLimit(20).
+		GetStatementAndParams()
+
+	if testx.CompareString("next page", "SELECT * FROM tablename WHERE owner = $1 AND (created, id) > ($2, $3) ORDER BY created ASC,id ASC LIMIT $4", stb, t) {
+		return
+	}
+
+	if len(params) != 4 || fmt.Sprint(params[2]) != "9007199254740993" {
+		t.Errorf("Params are wrong [Actual: %v]", params)
+		return
+	}
+
+	_, _, err = NewSelectStatement("*", "tablename").Keyset([]string{"id"}, false, "not a cursor").Build()
+	if err == nil {
+		t.Errorf("expected error for invalid cursor")
+	}
+}
+
+func TestStatementBuilderMultipleOrderBy(t *testing.T) {
+	whitelist := NewSortWhitelist("name").WithAlias("created", "created_at")
+
+	orderBy, err := ParseSortParameter("-created, name", whitelist)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	stb, _ := NewSelectStatement("*", "tablename").
+		OrderByAll(orderBy).
+		OrderBy(&StatementOrderBy{By: "deleted", Nulls: NullsFirst}).
+		GetStatementAndParams()
+
+	if testx.CompareString("statement", "SELECT * FROM tablename ORDER BY created_at DESC,name ASC,deleted ASC NULLS FIRST", stb, t) {
+		return
+	}
+
+	_, err = ParseSortParameter("-password", whitelist)
+	if err == nil {
+		t.Errorf("expected error for column not in whitelist")
+	}
+}
+
+func TestStatementBuilderCountStatement(t *testing.T) {
+	builder := NewSelectStatement("*", "tablename").
+		AddEqualCondition("label", 45).
+		OrderBy(&StatementOrderBy{By: "created"}).
+		AddOffset(20).
+		AddLimit(10)
+
+	stb, params := builder.CountStatement().GetStatementAndParams()
+	if testx.CompareString("count statement", "SELECT count(*) FROM tablename WHERE label = $1", stb, t) {
+		return
+	}
+
+	if len(params) != 1 || params[0] != 45 {
+		t.Errorf("Params are wrong [Actual: %v]", params)
+		return
+	}
+
+	stb, _ = builder.Select("owner", As(CountOf("*"), "n")).GroupBy("owner").CountStatement().GetStatementAndParams()
+	if testx.CompareString("grouped count statement", "SELECT count(*) FROM (SELECT owner,count(*) AS n FROM tablename WHERE label = $1 GROUP BY owner) AS counted", stb, t) {
+		return
+	}
+
+	stb, _ = NewSelectStatement("DISTINCT owner", "accounts").AddEqualCondition("active", true).CountStatement().GetStatementAndParams()
+	if testx.CompareString("distinct count statement", "SELECT count(*) FROM (SELECT DISTINCT owner FROM accounts WHERE active = $1) AS counted", stb, t) {
+		return
+	}
+
+	stb, _ = NewSelectStatement("*", "accounts").Select(MaxOf("balance")).CountStatement().GetStatementAndParams()
+	if testx.CompareString("aggregate count statement", "SELECT count(*) FROM (SELECT max(balance) FROM accounts) AS counted", stb, t) {
+		return
+	}
+
+	stb, _ = NewSelectStatement("accounts.*, owner AS name", "accounts").CountStatement().GetStatementAndParams()
+	if testx.CompareString("plain count statement", "SELECT count(*) FROM accounts", stb, t) {
+		return
+	}
+
+	// the original builder is untouched
+	stb, _ = builder.GetStatementAndParams()
+	if testx.CompareString("statement", "SELECT * FROM tablename WHERE label = $1 ORDER BY created ASC OFFSET $2 LIMIT $3", stb, t) {
+		return
+	}
+}
+
+func TestStatementBuilderGetStatementAndParamsLegacy(t *testing.T) {
+	// expression labels are passed through by the legacy method, only Build validates them
+	builder := NewSelectStatement("*", "users").AddEqualCondition("lower(name)", "max")
+	stb, params := builder.GetStatementAndParams()
+	if testx.CompareString("expression label", "SELECT * FROM users WHERE lower(name) = $1", stb, t) {
+		return
+	}
+
+	if testx.CompareInt("params", 1, len(params), t) {
+		return
+	}
+
+	_, _, err := builder.Build()
+	if err == nil {
+		t.Errorf("expected Build to reject expression label")
+		return
+	}
+
+	// a rejected user supplied sort value is left out instead of failing the statement
+	whitelisted := NewSelectStatement("*", "users").
+		AddEqualCondition("name", "max").
+		OrderByWhitelisted(&StatementOrderBy{By: "password"}, NewSortWhitelist("name"))
+	stb, _ = whitelisted.GetStatementAndParams()
+	if testx.CompareString("rejected sort", "SELECT * FROM users WHERE name = $1", stb, t) {
+		return
+	}
+
+	_, _, err = whitelisted.Build()
+	if err == nil {
+		t.Errorf("expected Build to fail for rejected sort value")
+		return
+	}
+
+	// an invalid cursor starts from the first page
+	keyset := NewSelectStatement("*", "users").Keyset([]string{"id"}, false, "invalid cursor").AddLimit(10)
+	stb, _ = keyset.GetStatementAndParams()
+	if testx.CompareString("invalid cursor", "SELECT * FROM users ORDER BY id ASC LIMIT $1", stb, t) {
+		return
+	}
+}
+
+func TestValidateIdentifierSchemaQualified(t *testing.T) {
+	stb, _, err := NewSelectStatement("*", "tenant_a.orders").
+		Join("tenant_a.items", On("tenant_a.items.order_id", "tenant_a.orders.id")).
+		AddEqualCondition("tenant_a.orders.id", 1).
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "SELECT * FROM tenant_a.orders JOIN tenant_a.items ON tenant_a.items.order_id = tenant_a.orders.id WHERE tenant_a.orders.id = $1"
+	if testx.CompareString("statement", expected, stb, t) {
+		return
+	}
+
+	if ValidateIdentifier("a.b.c.d") == nil {
+		t.Errorf("expected error for four part name")
+	}
+}
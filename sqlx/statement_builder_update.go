@@ -0,0 +1,432 @@
+package sqlx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// returned when building an UPDATE or DELETE without condition, see AllowUnconditioned
+var ErrUnconditionedStatement = errors.New("refusing to build statement without condition")
+
+/*
+	Whether conditions, joined by AND, match every row regardless of its values
+ */
+func isUnconditioned(conditions []Condition) bool {
+	value, constant := constantCondition(AllOf(conditions...))
+	return constant && value
+}
+
+/*
+	Value of condition if it does not depend on any column, e.g. AllOf(), an empty NOT IN or NOT (FALSE)
+ */
+func constantCondition(condition Condition) (value bool, constant bool) {
+	switch it := condition.(type) {
+	case *groupCondition:
+		return it.constant()
+	case *notCondition:
+		if it.condition == nil {
+			return false, true
+		}
+		value, constant = constantCondition(it.condition)
+		return !value, constant
+	case *inCondition:
+		if len(it.values) == 0 {
+			return it.not, true
+		}
+	}
+
+	return false, false
+}
+
+// AND is decided by a constant FALSE, OR by a constant TRUE, otherwise all parts have to be constant
+func (it *groupCondition) constant() (bool, bool) {
+	deciding := it.operator == "OR"
+	allConstant := true
+	for _, v := range it.conditions {
+		if v == nil {
+			continue
+		}
+
+		value, constant := constantCondition(v)
+		if constant && value == deciding {
+			return deciding, true
+		}
+		allConstant = allConstant && constant
+	}
+
+	if !allConstant {
+		return false, false
+	}
+	return !deciding, true
+}
+
+//////////////////////////////////////
+//
+// UPDATE
+//
+/////////////////////////////////////
+
+type UpdateStatementBuilder struct {
+	table              string
+	set                []*setClause
+	conditions         []Condition
+	returning          []string
+	allowUnconditioned bool
+	dialect            Dialect
+	quoteIdentifiers   bool
+}
+
+type setClause struct {
+	column string
+	value  interface{}
+}
+
+func NewUpdateStatement(tableName string) *UpdateStatementBuilder {
+	return &UpdateStatementBuilder{
+		table:      tableName,
+		set:        make([]*setClause, 0),
+		conditions: make([]Condition, 0),
+		returning:  make([]string, 0),
+	}
+}
+
+/*
+	Only columns set are updated
+ */
+func (it *UpdateStatementBuilder) Set(column string, value interface{}) *UpdateStatementBuilder {
+	builder := it.copy()
+	builder.set = append(builder.set, &setClause{
+		column: column,
+		value:  value,
+	})
+	return builder
+}
+
+func (it *UpdateStatementBuilder) Where(condition Condition) *UpdateStatementBuilder {
+	if condition == nil {
+		return it
+	}
+
+	builder := it.copy()
+	builder.conditions = append(builder.conditions, condition)
+	return builder
+}
+
+func (it *UpdateStatementBuilder) AddEqualCondition(conditionLabel string, conditionValue interface{}) *UpdateStatementBuilder {
+	return it.Where(EqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *UpdateStatementBuilder) AddNotEqualCondition(conditionLabel string, conditionValue interface{}) *UpdateStatementBuilder {
+	return it.Where(NotEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *UpdateStatementBuilder) AddLessThanCondition(conditionLabel string, conditionValue interface{}) *UpdateStatementBuilder {
+	return it.Where(LessThanCondition(conditionLabel, conditionValue))
+}
+
+func (it *UpdateStatementBuilder) AddLessOrEqualCondition(conditionLabel string, conditionValue interface{}) *UpdateStatementBuilder {
+	return it.Where(LessOrEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *UpdateStatementBuilder) AddGreaterThanCondition(conditionLabel string, conditionValue interface{}) *UpdateStatementBuilder {
+	return it.Where(GreaterThanCondition(conditionLabel, conditionValue))
+}
+
+func (it *UpdateStatementBuilder) AddGreaterOrEqualCondition(conditionLabel string, conditionValue interface{}) *UpdateStatementBuilder {
+	return it.Where(GreaterOrEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *UpdateStatementBuilder) AddIsNullCondition(conditionLabel string) *UpdateStatementBuilder {
+	return it.Where(IsNullCondition(conditionLabel))
+}
+
+func (it *UpdateStatementBuilder) AddIsNotNullCondition(conditionLabel string) *UpdateStatementBuilder {
+	return it.Where(IsNotNullCondition(conditionLabel))
+}
+
+/*
+	Unlike on StatementBuilder empty values are not skipped but match no row
+ */
+func (it *UpdateStatementBuilder) AddInCondition(conditionLabel string, values []string) *UpdateStatementBuilder {
+	return it.Where(InCondition(conditionLabel, stringParams(values)))
+}
+
+func (it *UpdateStatementBuilder) AddNotInCondition(conditionLabel string, values []string) *UpdateStatementBuilder {
+	return it.Where(NotInCondition(conditionLabel, stringParams(values)))
+}
+
+func (it *UpdateStatementBuilder) AddRange(conditionLabel string, valuesFrom interface{}, valuesTo interface{}) *UpdateStatementBuilder {
+	return it.Where(RangeCondition(conditionLabel, valuesFrom, valuesTo))
+}
+
+func (it *UpdateStatementBuilder) Returning(columns ...string) *UpdateStatementBuilder {
+	builder := it.copy()
+	builder.returning = append(builder.returning, columns...)
+	return builder
+}
+
+/*
+	Allows updating every row of the table
+ */
+func (it *UpdateStatementBuilder) AllowUnconditioned() *UpdateStatementBuilder {
+	builder := it.copy()
+	builder.allowUnconditioned = true
+	return builder
+}
+
+func (it *UpdateStatementBuilder) WithDialect(dialect Dialect) *UpdateStatementBuilder {
+	builder := it.copy()
+	builder.dialect = dialect
+	return builder
+}
+
+func (it *UpdateStatementBuilder) WithQuotedIdentifiers() *UpdateStatementBuilder {
+	builder := it.copy()
+	builder.quoteIdentifiers = true
+	return builder
+}
+
+/*
+	UPDATE table SET c1 = $1,c2 = $2 WHERE ... RETURNING ...
+ */
+func (it *UpdateStatementBuilder) Build() (string, []interface{}, error) {
+	if len(it.set) == 0 {
+		return "", nil, fmt.Errorf("update of %v without any column set", it.table)
+	}
+
+	ctx := newRenderContext(it.dialect, it.quoteIdentifiers)
+
+	set := make([]string, len(it.set))
+	for k, v := range it.set {
+		set[k] = fmt.Sprintf("%v = %v", ctx.identifier(v.column), ctx.bind(v.value))
+	}
+
+	if isUnconditioned(it.conditions) && !it.allowUnconditioned {
+		return "", nil, ErrUnconditionedStatement
+	}
+
+	where := renderWhere(ctx, it.conditions)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("UPDATE %v SET %v", ctx.identifier(it.table), strings.Join(set, ",")))
+	buffer.WriteString(where)
+	buffer.WriteString(renderReturning(ctx, it.returning))
+
+	if ctx.err != nil {
+		return "", nil, ctx.err
+	}
+
+	statement, params := Rebind(it.dialect, buffer.String(), ctx.params)
+	return statement, params, nil
+}
+
+func (it *UpdateStatementBuilder) copy() *UpdateStatementBuilder {
+	builder := *it
+	builder.set = append(make([]*setClause, 0, len(it.set)+1), it.set...)
+	builder.conditions = append(make([]Condition, 0, len(it.conditions)+1), it.conditions...)
+	builder.returning = append(make([]string, 0, len(it.returning)), it.returning...)
+	return &builder
+}
+
+//////////////////////////////////////
+//
+// DELETE
+//
+/////////////////////////////////////
+
+type DeleteStatementBuilder struct {
+	table              string
+	conditions         []Condition
+	returning          []string
+	allowUnconditioned bool
+	dialect            Dialect
+	quoteIdentifiers   bool
+}
+
+func NewDeleteStatement(tableName string) *DeleteStatementBuilder {
+	return &DeleteStatementBuilder{
+		table:      tableName,
+		conditions: make([]Condition, 0),
+		returning:  make([]string, 0),
+	}
+}
+
+func (it *DeleteStatementBuilder) Where(condition Condition) *DeleteStatementBuilder {
+	if condition == nil {
+		return it
+	}
+
+	builder := it.copy()
+	builder.conditions = append(builder.conditions, condition)
+	return builder
+}
+
+func (it *DeleteStatementBuilder) AddEqualCondition(conditionLabel string, conditionValue interface{}) *DeleteStatementBuilder {
+	return it.Where(EqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *DeleteStatementBuilder) AddNotEqualCondition(conditionLabel string, conditionValue interface{}) *DeleteStatementBuilder {
+	return it.Where(NotEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *DeleteStatementBuilder) AddLessThanCondition(conditionLabel string, conditionValue interface{}) *DeleteStatementBuilder {
+	return it.Where(LessThanCondition(conditionLabel, conditionValue))
+}
+
+func (it *DeleteStatementBuilder) AddLessOrEqualCondition(conditionLabel string, conditionValue interface{}) *DeleteStatementBuilder {
+	return it.Where(LessOrEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *DeleteStatementBuilder) AddGreaterThanCondition(conditionLabel string, conditionValue interface{}) *DeleteStatementBuilder {
+	return it.Where(GreaterThanCondition(conditionLabel, conditionValue))
+}
+
+func (it *DeleteStatementBuilder) AddGreaterOrEqualCondition(conditionLabel string, conditionValue interface{}) *DeleteStatementBuilder {
+	return it.Where(GreaterOrEqualCondition(conditionLabel, conditionValue))
+}
+
+func (it *DeleteStatementBuilder) AddIsNullCondition(conditionLabel string) *DeleteStatementBuilder {
+	return it.Where(IsNullCondition(conditionLabel))
+}
+
+func (it *DeleteStatementBuilder) AddIsNotNullCondition(conditionLabel string) *DeleteStatementBuilder {
+	return it.Where(IsNotNullCondition(conditionLabel))
+}
+
+/*
+	Unlike on StatementBuilder empty values are not skipped but match no row
+ */
+func (it *DeleteStatementBuilder) AddInCondition(conditionLabel string, values []string) *DeleteStatementBuilder {
+	return it.Where(InCondition(conditionLabel, stringParams(values)))
+}
+
+func (it *DeleteStatementBuilder) AddNotInCondition(conditionLabel string, values []string) *DeleteStatementBuilder {
+	return it.Where(NotInCondition(conditionLabel, stringParams(values)))
+}
+
+func (it *DeleteStatementBuilder) AddRange(conditionLabel string, valuesFrom interface{}, valuesTo interface{}) *DeleteStatementBuilder {
+	return it.Where(RangeCondition(conditionLabel, valuesFrom, valuesTo))
+}
+
+func (it *DeleteStatementBuilder) Returning(columns ...string) *DeleteStatementBuilder {
+	builder := it.copy()
+	builder.returning = append(builder.returning, columns...)
+	return builder
+}
+
+/*
+	Allows deleting every row of the table
+ */
+func (it *DeleteStatementBuilder) AllowUnconditioned() *DeleteStatementBuilder {
+	builder := it.copy()
+	builder.allowUnconditioned = true
+	return builder
+}
+
+func (it *DeleteStatementBuilder) WithDialect(dialect Dialect) *DeleteStatementBuilder {
+	builder := it.copy()
+	builder.dialect = dialect
+	return builder
+}
+
+func (it *DeleteStatementBuilder) WithQuotedIdentifiers() *DeleteStatementBuilder {
+	builder := it.copy()
+	builder.quoteIdentifiers = true
+	return builder
+}
+
+/*
+	DELETE FROM table WHERE ... RETURNING ...
+ */
+func (it *DeleteStatementBuilder) Build() (string, []interface{}, error) {
+	ctx := newRenderContext(it.dialect, it.quoteIdentifiers)
+
+	if isUnconditioned(it.conditions) && !it.allowUnconditioned {
+		return "", nil, ErrUnconditionedStatement
+	}
+
+	where := renderWhere(ctx, it.conditions)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("DELETE FROM %v", ctx.identifier(it.table)))
+	buffer.WriteString(where)
+	buffer.WriteString(renderReturning(ctx, it.returning))
+
+	if ctx.err != nil {
+		return "", nil, ctx.err
+	}
+
+	statement, params := Rebind(it.dialect, buffer.String(), ctx.params)
+	return statement, params, nil
+}
+
+func (it *DeleteStatementBuilder) copy() *DeleteStatementBuilder {
+	builder := *it
+	builder.conditions = append(make([]Condition, 0, len(it.conditions)+1), it.conditions...)
+	builder.returning = append(make([]string, 0, len(it.returning)), it.returning...)
+	return &builder
+}
+
+func stringParams(values []string) []interface{} {
+	params := make([]interface{}, len(values))
+	for k, v := range values {
+		params[k] = v
+	}
+	return params
+}
+
+func renderReturning(ctx *renderContext, columns []string) string {
+	if len(columns) == 0 {
+		return ""
+	}
+
+	rendered := make([]string, len(columns))
+	for k, v := range columns {
+		rendered[k] = ctx.selector(v)
+	}
+
+	return " RETURNING " + strings.Join(rendered, ",")
+}
+
+//////////////////////////////////////
+//
+// Execution
+//
+/////////////////////////////////////
+
+/*
+	Executes statement, e.g. an UpdateStatementBuilder, and returns the number of affected rows.
+	Use Select or SelectInto to read RETURNING columns
+ */
+func (pg *SQLDB) ExecStatement(statement Statement) (int64, error) {
+	return pg.ExecStatementContext(context.Background(), statement)
+}
+
+func (pg *SQLDB) ExecStatementContext(ctx context.Context, statement Statement) (int64, error) {
+	return execStatement(ctx, pg.executor(), statement)
+}
+
+func (it *Tx) ExecStatement(statement Statement) (int64, error) {
+	return it.ExecStatementContext(context.Background(), statement)
+}
+
+func (it *Tx) ExecStatementContext(ctx context.Context, statement Statement) (int64, error) {
+	return execStatement(ctx, it.executor(), statement)
+}
+
+func execStatement(ctx context.Context, ex executor, statement Statement) (int64, error) {
+	query, params, err := statement.Build()
+	if err != nil {
+		return -1, err
+	}
+
+	result, err := ex.ExecContext(ctx, query, params...)
+	if err != nil {
+		return -1, err
+	}
+
+	return result.RowsAffected()
+}
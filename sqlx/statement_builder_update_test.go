@@ -0,0 +1,172 @@
+package sqlx
+
+import (
+	"testing"
+	"github.com/ellsol/gox/testx"
+)
+
+func TestUpdateStatementBuilder(t *testing.T) {
+	stb, params, err := NewUpdateStatement("accounts").
+		Where(AnyOf(EqualCondition("id", 1), EqualCondition("id", 2))).
+		Set("name", "max").
+		Set("balance", 100).
+		Returning("id", "balance").
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedStatement := "UPDATE accounts SET name = $1,balance = $2 WHERE (id = $3 OR id = $4) RETURNING id,balance"
+	if testx.CompareString("statement", expectedStatement, stb, t) {
+		return
+	}
+
+	if len(params) != 4 || params[0] != "max" || params[1] != 100 || params[2] != 1 || params[3] != 2 {
+		t.Errorf("Params are wrong [Actual: %v]", params)
+		return
+	}
+
+	_, _, err = NewUpdateStatement("accounts").Set("name", "max").Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement, got %v", err)
+		return
+	}
+
+	_, _, err = NewUpdateStatement("accounts").Set("name", "max").Where(AllOf()).Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement for empty AllOf, got %v", err)
+		return
+	}
+
+	_, _, err = NewUpdateStatement("accounts").Set("name", "max").Where(AllOf(NotInCondition("id", nil), Not(InCondition("id", nil)))).Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement for constant condition group, got %v", err)
+		return
+	}
+
+	stb, _, err = NewUpdateStatement("accounts").Set("name", "max").AllowUnconditioned().Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("unconditioned statement", "UPDATE accounts SET name = $1", stb, t) {
+		return
+	}
+}
+
+func TestDeleteStatementBuilder(t *testing.T) {
+	stb, params, err := NewDeleteStatement("accounts").
+		AddEqualCondition("owner", "max").
+		Where(LessThanCondition("balance", 0)).
+		Returning("id").
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("statement", "DELETE FROM accounts WHERE owner = $1 AND balance < $2 RETURNING id", stb, t) {
+		return
+	}
+
+	if len(params) != 2 || params[0] != "max" || params[1] != 0 {
+		t.Errorf("Params are wrong [Actual: %v]", params)
+		return
+	}
+
+	_, _, err = NewDeleteStatement("accounts").Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement, got %v", err)
+		return
+	}
+
+	_, _, err = NewDeleteStatement("accounts").Where(NotInCondition("id", nil)).Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement for empty NOT IN, got %v", err)
+		return
+	}
+
+	stb, _, err = NewDeleteStatement("accounts").Where(NotInCondition("id", nil)).AllowUnconditioned().Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("allowed statement", "DELETE FROM accounts WHERE TRUE", stb, t) {
+		return
+	}
+}
+
+func TestUnconditionedConditionTree(t *testing.T) {
+	_, _, err := NewDeleteStatement("accounts").Where(AnyOf(AllOf(), EqualCondition("id", 1))).Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement for OR with constant TRUE, got %v", err)
+		return
+	}
+
+	_, _, err = NewDeleteStatement("accounts").Where(Not(Not(AllOf()))).Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement for double negation, got %v", err)
+		return
+	}
+
+	_, _, err = NewUpdateStatement("accounts").Set("name", "max").Where(Not(AllOf(EqualCondition("id", 1), Not(AllOf())))).Build()
+	if err != ErrUnconditionedStatement {
+		t.Errorf("expected ErrUnconditionedStatement for negated AND with constant FALSE, got %v", err)
+		return
+	}
+
+	conditioned := []Condition{
+		AnyOf(AnyOf(), EqualCondition("id", 1)),
+		AllOf(AllOf(), EqualCondition("id", 1)),
+		Not(AnyOf(EqualCondition("id", 1), Not(AllOf()))),
+		InCondition("id", nil),
+		AllOf(NotInCondition("id", nil), Not(AllOf())),
+	}
+
+	for _, v := range conditioned {
+		_, _, err = NewDeleteStatement("accounts").Where(v).Build()
+		if err != nil {
+			t.Errorf("expected %v to be accepted, got %v", v, err)
+		}
+	}
+}
+
+func TestUpdateStatementConditionHelpers(t *testing.T) {
+	stb, params, err := NewUpdateStatement("accounts").
+		Set("active", false).
+		AddInCondition("owner", []string{"max", "eva"}).
+		AddRange("balance", 0, 100).
+		AddGreaterThanCondition("created", 10).
+		AddIsNullCondition("deleted").
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "UPDATE accounts SET active = $1 WHERE owner IN ($2,$3) AND balance BETWEEN $4 AND $5 AND created > $6 AND deleted IS NULL"
+	if testx.CompareString("statement", expected, stb, t) {
+		return
+	}
+
+	if testx.CompareInt("params", 6, len(params), t) {
+		return
+	}
+
+	stb, _, err = NewDeleteStatement("accounts").
+		AddInCondition("id", nil).
+		AddNotEqualCondition("owner", "max").
+		Build()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// an empty IN is kept and matches no row
+	if testx.CompareString("empty in", "DELETE FROM accounts WHERE FALSE AND owner <> $1", stb, t) {
+		return
+	}
+}
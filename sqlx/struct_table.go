@@ -0,0 +1,292 @@
+package sqlx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const StructTag = "db"
+
+/*
+	Column information parsed from a struct field tagged with
+	`db:"name,pk,notnull,type=BIGINT"`. Fields tagged with json are stored as JSONB,
+	slices other than []byte as arrays
+ */
+type structField struct {
+	Column    string
+	Type      string
+	IsPrimary bool
+	NotNull   bool
+	JSON      bool
+	Array     bool
+	Index     []int
+}
+
+// value of the field as passed to the driver
+func (it structField) value(field reflect.Value) interface{} {
+	switch {
+	case it.JSON:
+		return JSONValue(field.Interface())
+	case it.Array:
+		return ArrayValue(field.Interface())
+	}
+	return field.Interface()
+}
+
+// scan destination of the field
+func (it structField) scanTarget(field reflect.Value) interface{} {
+	switch {
+	case it.JSON:
+		return JSONScanner(field.Addr().Interface())
+	case it.Array:
+		return ArrayScanner(field.Addr().Interface())
+	}
+	return field.Addr().Interface()
+}
+
+/*
+	Derives a table definition from the db tags of model, which has to be a struct or a pointer to one.
+	Columns are created in field order, the sql type is taken from type=... or derived from the go type
+ */
+func TableDefinitionFromStruct(tableName string, model interface{}) (*SQLTableDefinition, error) {
+	fields, err := structFieldsOf(model)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := NewSQLTableBuilder(tableName)
+	for _, v := range fields {
+		if v.Type == "" {
+			return nil, fmt.Errorf("no sql type known for column %v of table %v, add type=... to its tag", v.Column, tableName)
+		}
+
+		builder.WithColumn(&SQLTableColumn{
+			Name:      v.Column,
+			Type:      v.Type,
+			IsPrimary: v.IsPrimary,
+			NotNULL:   v.NotNull,
+		})
+	}
+
+	return builder.Build(), nil
+}
+
+/*
+	Column names of the db tagged fields of model in field order
+ */
+func StructColumnNames(model interface{}) ([]string, error) {
+	fields, err := structFieldsOf(model)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(fields))
+	for k, v := range fields {
+		result[k] = v.Column
+	}
+	return result, nil
+}
+
+/*
+	Values of model ordered by table.ColumnNames(), ready to be passed to Insert or Update.
+	Fails if a column of the table has no matching db tag in model
+ */
+func StructValues(table SQLTable, model interface{}) ([]interface{}, error) {
+	return structValuesForColumns(table.Name(), table.ColumnNames(), model)
+}
+
+/*
+	Like StructValues but without the primary key columns, matching InsertOmitPrimary
+ */
+func StructValuesOmitPrimary(table SQLTable, model interface{}) ([]interface{}, error) {
+	return structValuesForColumns(table.Name(), ColumnNamesOmitPrimary(table), model)
+}
+
+func structValuesForColumns(tableName string, columns []string, model interface{}) ([]interface{}, error) {
+	fields, err := structFieldsOf(model)
+	if err != nil {
+		return nil, err
+	}
+
+	byColumn := make(map[string]structField, len(fields))
+	for _, v := range fields {
+		byColumn[v.Column] = v
+	}
+
+	value := reflect.ValueOf(model)
+	if value.Kind() == reflect.Ptr && value.IsNil() {
+		return nil, fmt.Errorf("expected struct, got nil %v", value.Type())
+	}
+
+	value = reflect.Indirect(value)
+	result := make([]interface{}, len(columns))
+	for k, column := range columns {
+		field, ok := byColumn[column]
+		if !ok {
+			return nil, fmt.Errorf("column %v of table %v has no matching field in %v", column, tableName, value.Type())
+		}
+
+		result[k] = field.value(value.FieldByIndex(field.Index))
+	}
+
+	return result, nil
+}
+
+func structFieldsOf(model interface{}) ([]structField, error) {
+	if model == nil {
+		return nil, fmt.Errorf("expected struct, got nil")
+	}
+
+	t := reflect.TypeOf(model)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected struct, got %v", t)
+	}
+
+	return structFields(t, nil)
+}
+
+func structFields(t reflect.Type, parentIndex []int) ([]structField, error) {
+	result := make([]structField, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append(make([]int, 0, len(parentIndex)+1), parentIndex...), i)
+		tag, hasTag := field.Tag.Lookup(StructTag)
+
+		// embedded structs without own tag contribute their fields
+		if field.Anonymous && !hasTag && field.Type.Kind() == reflect.Struct {
+			embedded, err := structFields(field.Type, index)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, embedded...)
+			continue
+		}
+
+		if !hasTag || tag == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		parsed, err := parseStructTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("field %v of %v: %v", field.Name, t, err)
+		}
+
+		if parsed.Column == "" {
+			parsed.Column = strings.ToLower(field.Name)
+		}
+
+		if !parsed.JSON && isArrayType(field.Type) {
+			parsed.Array = true
+		}
+
+		if parsed.Type == "" && parsed.JSON {
+			parsed.Type = "JSONB"
+		}
+
+		if parsed.Type == "" {
+			parsed.Type = sqlTypeOf(field.Type)
+		}
+
+		parsed.Index = index
+		result = append(result, parsed)
+	}
+
+	return result, nil
+}
+
+func parseStructTag(tag string) (structField, error) {
+	parts := strings.Split(tag, ",")
+	result := structField{
+		Column: strings.TrimSpace(parts[0]),
+	}
+
+	for _, v := range parts[1:] {
+		option := strings.TrimSpace(v)
+		switch {
+		case option == "pk":
+			result.IsPrimary = true
+		case option == "notnull":
+			result.NotNull = true
+		case option == "json":
+			result.JSON = true
+		case strings.HasPrefix(option, "type="):
+			result.Type = strings.TrimPrefix(option, "type=")
+		case option == "":
+		default:
+			return result, fmt.Errorf("unknown option %v in tag %v", option, tag)
+		}
+	}
+
+	return result, nil
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	nullStringType = reflect.TypeOf(sql.NullString{})
+	nullInt64Type  = reflect.TypeOf(sql.NullInt64{})
+	nullBoolType   = reflect.TypeOf(sql.NullBool{})
+	nullFloatType  = reflect.TypeOf(sql.NullFloat64{})
+	valuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+/*
+	Slices stored as array column, []byte and types converting themselves are excluded
+ */
+func isArrayType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !t.Implements(valuerType)
+}
+
+/*
+	Default sql type for a go type, empty if unknown
+ */
+func sqlTypeOf(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return "TIMESTAMPTZ"
+	case nullStringType:
+		return "TEXT"
+	case nullInt64Type:
+		return "BIGINT"
+	case nullBoolType:
+		return "BOOLEAN"
+	case nullFloatType:
+		return "DOUBLE PRECISION"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "TEXT"
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "BIGINT"
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "INT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "BYTEA"
+		}
+		if element := sqlTypeOf(t.Elem()); element != "" {
+			return ArrayType(element)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,146 @@
+package sqlx
+
+import (
+	"database/sql/driver"
+	"testing"
+	"github.com/ellsol/gox/testx"
+)
+
+type testAuditFields struct {
+	Created int64 `db:"created,notnull"`
+}
+
+type testAccount struct {
+	ID      int    `db:"id,pk,type=SERIAL"`
+	Name    string `db:"name,notnull"`
+	Balance int64  `db:"balance"`
+	Data    []byte `db:"data"`
+	Ignored string `db:"-"`
+	testAuditFields
+}
+
+func TestTableDefinitionFromStruct(t *testing.T) {
+	definition, err := TableDefinitionFromStruct("accounts", &testAccount{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "CREATE TABLE accounts(id SERIAL PRIMARY KEY,name TEXT NOT NULL,balance BIGINT,data BYTEA,created BIGINT NOT NULL);"
+	if testx.CompareString("create statement", expected, definition.CreateStatement(), t) {
+		return
+	}
+
+	_, err = TableDefinitionFromStruct("broken", &struct {
+		Value map[string]string `db:"value"`
+	}{})
+	if err == nil {
+		t.Errorf("expected error for column without sql type")
+	}
+}
+
+func TestStructValues(t *testing.T) {
+	table := &testTable{name: "accounts", columns: []string{"id", "balance", "name"}}
+	account := testAccount{ID: 3, Name: "max", Balance: 100}
+
+	values, err := StructValues(table, account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(values) != 3 || values[0] != 3 || values[1] != int64(100) || values[2] != "max" {
+		t.Errorf("values in wrong order: %v", values)
+		return
+	}
+
+	values, err = StructValuesOmitPrimary(table, &account)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(values) != 2 || values[0] != int64(100) {
+		t.Errorf("values in wrong order: %v", values)
+		return
+	}
+
+	_, err = StructValues(&testTable{name: "accounts", columns: []string{"unknown"}}, account)
+	if err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+}
+
+func TestStructValuesNilPointer(t *testing.T) {
+	table := &testTable{name: "accounts", columns: []string{"id", "balance", "name"}}
+
+	_, err := StructValues(table, (*testAccount)(nil))
+	if err == nil {
+		t.Errorf("expected error for nil pointer")
+		return
+	}
+
+	if testx.CompareString("error", "expected struct, got nil *sqlx.testAccount", err.Error(), t) {
+		return
+	}
+
+	// only the type is needed for the definition
+	_, err = TableDefinitionFromStruct("accounts", (*testAccount)(nil))
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+type testDocument struct {
+	ID   int               `db:"id,pk,type=SERIAL"`
+	Tags []string          `db:"tags"`
+	Meta map[string]string `db:"meta,json"`
+}
+
+func TestStructValuesConversion(t *testing.T) {
+	definition, err := TableDefinitionFromStruct("documents", &testDocument{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := "CREATE TABLE documents(id SERIAL PRIMARY KEY,tags TEXT[],meta JSONB);"
+	if testx.CompareString("create statement", expected, definition.CreateStatement(), t) {
+		return
+	}
+
+	table := &testTable{name: "documents", columns: []string{"tags", "meta"}}
+	values, err := StructValues(table, &testDocument{Tags: []string{"a", "b"}, Meta: map[string]string{"k": "v"}})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	tags, err := values[0].(driver.Valuer).Value()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	meta, err := values[1].(driver.Valuer).Value()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("tags", `{"a","b"}`, tags.(string), t) ||
+		testx.CompareString("meta", `{"k":"v"}`, meta.(string), t) {
+		return
+	}
+
+	var scanned map[string]string
+	err = JSONScanner(&scanned).Scan([]byte(`{"k":"w"}`))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if testx.CompareString("scanned", "w", scanned["k"], t) {
+		return
+	}
+}
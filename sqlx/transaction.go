@@ -0,0 +1,199 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const SerializationFailureCode = "40001"
+
+/*
+	Transaction exposing the same operations as SQLDB
+ */
+type Tx struct {
+	Connection *sql.Tx
+	// Postgres if not set
+	Dialect Dialect
+}
+
+// additional attempts of WithTransaction after a serialization failure
+const DefaultTransactionRetries = 3
+
+type TransactionOptions struct {
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// number of additional attempts if the transaction fails with a serialization failure
+	MaxRetries int
+}
+
+func DefaultTransactionOptions() *TransactionOptions {
+	return &TransactionOptions{
+		Isolation:  sql.LevelDefault,
+		MaxRetries: DefaultTransactionRetries,
+	}
+}
+
+func (pg *SQLDB) Begin(ctx context.Context, options *TransactionOptions) (*Tx, error) {
+	if options == nil {
+		options = DefaultTransactionOptions()
+	}
+
+	tx, err := pg.Connection.BeginTx(ctx, &sql.TxOptions{
+		Isolation: options.Isolation,
+		ReadOnly:  options.ReadOnly,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{
+		Connection: tx,
+		Dialect:    pg.Dialect,
+	}, nil
+}
+
+/*
+	Runs fn inside a transaction with default options. Commits if fn returns nil, rolls back otherwise or if fn panics.
+	Retried up to DefaultTransactionRetries times on serialization failures, so fn may run more than once
+ */
+func (pg *SQLDB) WithTransaction(ctx context.Context, fn func(tx *Tx) error) error {
+	return pg.WithTransactionOptions(ctx, DefaultTransactionOptions(), fn)
+}
+
+/*
+	Like WithTransaction, but retries the whole transaction up to options.MaxRetries times
+	if it fails with a serialization failure (SQLSTATE 40001)
+ */
+func (pg *SQLDB) WithTransactionOptions(ctx context.Context, options *TransactionOptions, fn func(tx *Tx) error) error {
+	if options == nil {
+		options = DefaultTransactionOptions()
+	}
+
+	var err error
+	for attempt := 0; attempt <= options.MaxRetries; attempt++ {
+		err = pg.runTransaction(ctx, options, fn)
+		if err == nil || !IsSerializationFailure(err) {
+			return err
+		}
+
+		logMsg(fmt.Sprintf("transaction attempt %v failed with serialization failure: %v", attempt+1, err))
+	}
+
+	return err
+}
+
+func (pg *SQLDB) runTransaction(ctx context.Context, options *TransactionOptions, fn func(tx *Tx) error) (err error) {
+	tx, err := pg.Begin(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil && rollbackErr != sql.ErrTxDone {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func IsSerializationFailure(err error) bool {
+	return errors.Is(ClassifyError(err), ErrSerialization)
+}
+
+func (it *Tx) executor() executor {
+	return newDialectExecutor(it.Connection, it.Dialect)
+}
+
+func (it *Tx) Commit() error {
+	return it.Connection.Commit()
+}
+
+func (it *Tx) Rollback() error {
+	return it.Connection.Rollback()
+}
+
+func (it *Tx) Insert(table SQLTable, values []interface{}) (int, error) {
+	return it.InsertContext(context.Background(), table, values)
+}
+
+func (it *Tx) InsertContext(ctx context.Context, table SQLTable, values []interface{}) (int, error) {
+	return insert(ctx, it.executor(), table, values)
+}
+
+func (it *Tx) InsertOmitPrimary(table SQLTable, values []interface{}) (int, error) {
+	return it.InsertOmitPrimaryContext(context.Background(), table, values)
+}
+
+func (it *Tx) InsertOmitPrimaryContext(ctx context.Context, table SQLTable, values []interface{}) (int, error) {
+	return insertOmitPrimary(ctx, it.executor(), table, values)
+}
+
+func (it *Tx) Update(table SQLTable, keyLabel string, values []interface{}) error {
+	return it.UpdateContext(context.Background(), table, keyLabel, values)
+}
+
+func (it *Tx) UpdateContext(ctx context.Context, table SQLTable, keyLabel string, values []interface{}) error {
+	statement := CreateUpdateStatement(table, keyLabel)
+	return it.UpdateWithStatementContext(ctx, statement, table, values)
+}
+
+func (it *Tx) UpdateWithStatement(statement string, table SQLTable, values []interface{}) error {
+	return it.UpdateWithStatementContext(context.Background(), statement, table, values)
+}
+
+func (it *Tx) UpdateWithStatementContext(ctx context.Context, statement string, table SQLTable, values []interface{}) error {
+	return updateWithStatement(ctx, it.executor(), statement, table, values)
+}
+
+func (it *Tx) Delete(key interface{}, keyLabel string, table SQLTable) error {
+	return it.DeleteContext(context.Background(), key, keyLabel, table)
+}
+
+func (it *Tx) DeleteContext(ctx context.Context, key interface{}, keyLabel string, table SQLTable) error {
+	return deleteRow(ctx, it.executor(), key, keyLabel, table)
+}
+
+func (it *Tx) Count(table SQLTable) (int, error) {
+	return it.CountContext(context.Background(), table)
+}
+
+func (it *Tx) CountContext(ctx context.Context, table SQLTable) (int, error) {
+	return countRows(ctx, it.executor(), table)
+}
+
+func (it *Tx) CountByStatement(table SQLTable, statement string, params ...interface{}) (int, error) {
+	return it.CountByStatementContext(context.Background(), table, statement, params...)
+}
+
+func (it *Tx) CountByStatementContext(ctx context.Context, table SQLTable, statement string, params ...interface{}) (int, error) {
+	return countByStatement(ctx, it.executor(), statement, params...)
+}
+
+func (it *Tx) Max(table SQLTable, column string) (int64, error) {
+	return it.MaxContext(context.Background(), table, column)
+}
+
+func (it *Tx) MaxContext(ctx context.Context, table SQLTable, column string) (int64, error) {
+	return maxOfColumn(ctx, it.executor(), table, column)
+}
+
+func (it *Tx) Select(builder Statement) (*sql.Rows, error) {
+	return it.SelectContext(context.Background(), builder)
+}
+
+func (it *Tx) SelectContext(ctx context.Context, builder Statement) (*sql.Rows, error) {
+	return selectRows(ctx, it.executor(), builder)
+}
@@ -0,0 +1,288 @@
+package sqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/ellsol/gox/testx"
+	"github.com/lib/pq"
+	"io"
+	"sync"
+	"testing"
+)
+
+//////////////////////////////////////
+//
+// fake driver recording transactions
+//
+/////////////////////////////////////
+
+type fakeState struct {
+	commits     int
+	rollbacks   int
+	rollbackErr error
+	// returned by every query unless onQuery is set
+	columns []string
+	rows    [][]driver.Value
+	// every executed statement and query in order
+	statements []string
+	onExec     func(query string, args []driver.Value) error
+	onQuery    func(query string, args []driver.Value) ([]string, [][]driver.Value)
+}
+
+var (
+	fakeStates      = make(map[string]*fakeState)
+	fakeStatesMutex sync.Mutex
+)
+
+func init() {
+	sql.Register("sqlxfake", fakeDriver{})
+}
+
+// SQLDB on a fake connection, the returned state is shared by all connections of the pool
+func openFakeDB(t *testing.T) (*SQLDB, *fakeState) {
+	state := &fakeState{}
+	fakeStatesMutex.Lock()
+	fakeStates[t.Name()] = state
+	fakeStatesMutex.Unlock()
+
+	connection, err := sql.Open("sqlxfake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { connection.Close() })
+
+	return &SQLDB{Connection: connection}, state
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeStatesMutex.Lock()
+	defer fakeStatesMutex.Unlock()
+	return &fakeConn{state: fakeStates[name]}, nil
+}
+
+type fakeConn struct {
+	state *fakeState
+}
+
+func (it *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{state: it.state, query: query}, nil
+}
+
+func (it *fakeConn) Close() error {
+	return nil
+}
+
+func (it *fakeConn) Begin() (driver.Tx, error) {
+	return &fakeTx{state: it.state}, nil
+}
+
+type fakeTx struct {
+	state *fakeState
+}
+
+func (it *fakeTx) Commit() error {
+	it.state.commits++
+	return nil
+}
+
+func (it *fakeTx) Rollback() error {
+	it.state.rollbacks++
+	return it.state.rollbackErr
+}
+
+type fakeStmt struct {
+	state *fakeState
+	query string
+}
+
+func (it *fakeStmt) Close() error {
+	return nil
+}
+
+func (it *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (it *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	it.state.statements = append(it.state.statements, it.query)
+	if it.state.onExec != nil {
+		err := it.state.onExec(it.query, args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (it *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	it.state.statements = append(it.state.statements, it.query)
+	if it.state.onQuery != nil {
+		columns, rows := it.state.onQuery(it.query, args)
+		return &fakeRows{columns: columns, rows: rows}, nil
+	}
+	return &fakeRows{columns: it.state.columns, rows: it.state.rows}, nil
+}
+
+type fakeRows struct {
+	columns  []string
+	rows     [][]driver.Value
+	position int
+}
+
+func (it *fakeRows) Columns() []string {
+	return it.columns
+}
+
+func (it *fakeRows) Close() error {
+	return nil
+}
+
+func (it *fakeRows) Next(dest []driver.Value) error {
+	if it.position >= len(it.rows) {
+		return io.EOF
+	}
+
+	copy(dest, it.rows[it.position])
+	it.position++
+	return nil
+}
+
+//////////////////////////////////////
+//
+// tests
+//
+/////////////////////////////////////
+
+func TestWithTransactionCommit(t *testing.T) {
+	db, state := openFakeDB(t)
+
+	calls := 0
+	err := db.WithTransaction(context.Background(), func(tx *Tx) error {
+		calls++
+		_, err := tx.Connection.Exec("UPDATE accounts SET name = $1", "x")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("calls", 1, calls, t) {
+		return
+	}
+
+	if testx.CompareInt("commits", 1, state.commits, t) {
+		return
+	}
+
+	if testx.CompareInt("rollbacks", 0, state.rollbacks, t) {
+		return
+	}
+}
+
+func TestWithTransactionRollback(t *testing.T) {
+	db, state := openFakeDB(t)
+
+	failure := errors.New("failed")
+	calls := 0
+	err := db.WithTransaction(context.Background(), func(tx *Tx) error {
+		calls++
+		return failure
+	})
+	if err != failure {
+		t.Errorf("Param err [Expected %v, Actual: %v]", failure, err)
+	}
+
+	// not a serialization failure, no retry
+	if testx.CompareInt("calls", 1, calls, t) {
+		return
+	}
+
+	if testx.CompareInt("commits", 0, state.commits, t) {
+		return
+	}
+
+	if testx.CompareInt("rollbacks", 1, state.rollbacks, t) {
+		return
+	}
+}
+
+func TestWithTransactionRollbackOnPanic(t *testing.T) {
+	db, state := openFakeDB(t)
+
+	func() {
+		defer func() {
+			r := recover()
+			if r != "boom" {
+				t.Errorf("Param recovered [Expected boom, Actual: %v]", r)
+			}
+		}()
+
+		db.WithTransaction(context.Background(), func(tx *Tx) error {
+			panic("boom")
+		})
+	}()
+
+	if testx.CompareInt("commits", 0, state.commits, t) {
+		return
+	}
+
+	if testx.CompareInt("rollbacks", 1, state.rollbacks, t) {
+		return
+	}
+}
+
+func TestWithTransactionRetry(t *testing.T) {
+	db, state := openFakeDB(t)
+
+	calls := 0
+	err := db.WithTransaction(context.Background(), func(tx *Tx) error {
+		calls++
+		if calls < 3 {
+			return &pq.Error{Code: SerializationFailureCode}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testx.CompareInt("calls", 3, calls, t) {
+		return
+	}
+
+	if testx.CompareInt("commits", 1, state.commits, t) {
+		return
+	}
+
+	if testx.CompareInt("rollbacks", 2, state.rollbacks, t) {
+		return
+	}
+}
+
+func TestWithTransactionRetryExhausted(t *testing.T) {
+	db, state := openFakeDB(t)
+	state.rollbackErr = errors.New("rollback failed")
+
+	calls := 0
+	err := db.WithTransactionOptions(context.Background(), &TransactionOptions{MaxRetries: 1}, func(tx *Tx) error {
+		calls++
+		return &pq.Error{Code: SerializationFailureCode}
+	})
+
+	// the failed rollback must not hide the serialization failure
+	if !IsSerializationFailure(err) {
+		t.Errorf("Param err [Expected serialization failure, Actual: %v]", err)
+	}
+
+	if testx.CompareInt("calls", 2, calls, t) {
+		return
+	}
+
+	if testx.CompareInt("commits", 0, state.commits, t) {
+		return
+	}
+}